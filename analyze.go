@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // Analyze ast and register variables to env
@@ -25,6 +27,15 @@ func analyzeStatement(statement Statement, env *Env) []error {
 	case *Declaration:
 		errs = analyzeDeclaration(s, env)
 
+	case *StructDeclaration:
+		errs = analyzeStructDeclaration(s, env)
+
+	case *EnumDeclaration:
+		errs = analyzeEnumDeclaration(s, env)
+
+	case *TypedefDeclaration:
+		errs = analyzeTypedefDeclaration(s, env)
+
 	case *CompoundStatement:
 		errs = analyzeCompoundStatement(s, env)
 
@@ -36,8 +47,58 @@ func analyzeStatement(statement Statement, env *Env) []error {
 	case *WhileStatement:
 		// ForStatement is converted to WhileStatement
 		errs = analyzeExpression(s.Condition, env)
+
+		// Set special symbol to check break and continue
+		loopEnv := env.CreateChild()
+		loopEnv.Add(&Symbol{Name: "#loop"})
+
+		errs = append(errs, analyzeStatement(s.Statement, loopEnv)...)
+		errs = append(errs, analyzeStatement(s.Loop, env)...)
+
+	case *DoWhileStatement:
+		loopEnv := env.CreateChild()
+		loopEnv.Add(&Symbol{Name: "#loop"})
+
+		errs = analyzeStatement(s.Statement, loopEnv)
+		errs = append(errs, analyzeExpression(s.Condition, env)...)
+
+	case *SwitchStatement:
+		errs = analyzeExpression(s.Value, env)
+
+		// Set special symbol to check case and break
+		switchEnv := env.CreateChild()
+		switchEnv.Add(&Symbol{Name: "#switch"})
+
+		errs = append(errs, analyzeStatement(s.Statement, switchEnv)...)
+		errs = append(errs, analyzeSwitchCases(s)...)
+
+	case *CaseStatement:
+		if env.Get("#switch") == nil {
+			errs = append(errs, SemanticError{
+				Pos: s.Pos(),
+				Err: errors.New("`case` and `default` must be in a switch"),
+			})
+		}
+
+		errs = append(errs, analyzeExpression(s.Value, env)...)
 		errs = append(errs, analyzeStatement(s.Statement, env)...)
 
+	case *BreakStatement:
+		if env.Get("#loop") == nil && env.Get("#switch") == nil {
+			errs = append(errs, SemanticError{
+				Pos: s.Pos(),
+				Err: errors.New("`break` must be in a loop or a switch"),
+			})
+		}
+
+	case *ContinueStatement:
+		if env.Get("#loop") == nil {
+			errs = append(errs, SemanticError{
+				Pos: s.Pos(),
+				Err: errors.New("`continue` must be in a loop"),
+			})
+		}
+
 	case *ExpressionStatement:
 		errs = analyzeExpression(s.Value, env)
 
@@ -51,6 +112,138 @@ func analyzeStatement(statement Statement, env *Env) []error {
 	return errs
 }
 
+// analyzeSwitchCases collects the cases of s and checks their values
+func analyzeSwitchCases(s *SwitchStatement) []error {
+	var errs []error
+
+	s.Cases = findCaseStatements(s.Statement)
+
+	hasDefault := false
+	values := map[int]bool{}
+	for _, c := range s.Cases {
+		if c.Value == nil {
+			if hasDefault {
+				errs = append(errs, SemanticError{
+					Pos: c.Pos(),
+					Err: errors.New("multiple default labels in one switch"),
+				})
+			}
+
+			hasDefault = true
+			continue
+		}
+
+		isConstant, value := evaluateConstant(c.Value)
+		if !isConstant {
+			errs = append(errs, SemanticError{
+				Pos: c.Pos(),
+				Err: errors.New("case value must be a constant integer"),
+			})
+			continue
+		}
+
+		if values[value] {
+			errs = append(errs, SemanticError{
+				Pos: c.Pos(),
+				Err: fmt.Errorf("duplicate case value `%d`", value),
+			})
+		}
+
+		values[value] = true
+	}
+
+	return errs
+}
+
+// findCaseStatements returns cases in statement except those of nested switches
+func findCaseStatements(statement Statement) []*CaseStatement {
+	var cases []*CaseStatement
+
+	switch s := statement.(type) {
+	case *CaseStatement:
+		cases = append(cases, s)
+		cases = append(cases, findCaseStatements(s.Statement)...)
+
+	case *CompoundStatement:
+		for _, statement := range s.Statements {
+			cases = append(cases, findCaseStatements(statement)...)
+		}
+
+	case *IfStatement:
+		for _, statement := range s.Statements() {
+			cases = append(cases, findCaseStatements(statement)...)
+		}
+
+	case *WhileStatement:
+		for _, statement := range s.Statements() {
+			cases = append(cases, findCaseStatements(statement)...)
+		}
+
+	case *DoWhileStatement:
+		cases = findCaseStatements(s.Statement)
+	}
+
+	return cases
+}
+
+// evaluateConstant returns the value of a constant integer expression
+func evaluateConstant(expression Expression) (bool, int) {
+	switch e := expression.(type) {
+	case *NumberExpression:
+		value, err := strconv.Atoi(e.Value)
+		return err == nil, value
+
+	case *IdentifierExpression:
+		if e.Symbol != nil && e.Symbol.IsConstant() {
+			return true, e.Symbol.Value
+		}
+
+	case *UnaryExpression:
+		isConstant, value := evaluateConstant(e.Value)
+		if !isConstant {
+			return false, 0
+		}
+
+		switch e.Operator {
+		case "-":
+			return calculate("-", 0, value)
+
+		case "~":
+			return true, ^value
+
+		case "!":
+			return calculate("!", value, 0)
+		}
+
+	case *SizeofExpression:
+		t, err := typeOfSizeof(e)
+		return err == nil, t.ByteSize()
+
+	case *CastExpression:
+		isConstant, value := evaluateConstant(e.Value)
+		if isConstant && e.Type != nil && e.Type.ByteSize() == 1 {
+			// (char) 300 == 44
+			value = int(int8(value))
+		}
+
+		return isConstant, value
+
+	case *BinaryExpression:
+		if e.IsAssignment() || e.IsLogical() {
+			return false, 0
+		}
+
+		leftIsConstant, left := evaluateConstant(e.Left)
+		rightIsConstant, right := evaluateConstant(e.Right)
+
+		if leftIsConstant && rightIsConstant {
+			return calculate(operatorOf(e), left, right)
+		}
+	}
+
+	return false, 0
+}
+
 func analyzeFunctionDefinition(s *FunctionDefinition, env *Env) []error {
 	errs := []error{}
 
@@ -60,12 +253,31 @@ func analyzeFunctionDefinition(s *FunctionDefinition, env *Env) []error {
 	for _, p := range s.Parameters {
 		parameter, ok := p.(*ParameterDeclaration)
 		if ok {
-			argType := BasicType{Name: parameter.TypeName}
-			argTypes = append(argTypes, composeType(parameter.Identifier, argType))
+			argType, err := resolveType(parameter.TypeName, env)
+			if err == nil && parameter.IsFunctionPointer {
+				argType, err = functionTypeOf(argType, parameter.ParameterTypes, env)
+			}
+
+			if err != nil {
+				errs = append(errs, SemanticError{
+					Pos: parameter.Pos(),
+					Err: err,
+				})
+			}
+
+			argTypes = append(argTypes, parameterType(parameter, argType))
 		}
 	}
 
-	returnType := composeType(s.Identifier, BasicType{Name: s.TypeName})
+	baseType, err := resolveType(s.TypeName, env)
+	if err != nil {
+		errs = append(errs, SemanticError{
+			Pos: s.Pos(),
+			Err: err,
+		})
+	}
+
+	returnType := composeType(s.Identifier, baseType)
 	symbolType := FunctionType{Return: returnType, Args: argTypes}
 
 	kind := ""
@@ -75,9 +287,16 @@ func analyzeFunctionDefinition(s *FunctionDefinition, env *Env) []error {
 		kind = "proto"
 	}
 
-	err := env.Register(identifier, &Symbol{
-		Kind: kind,
-		Type: symbolType,
+	// functions are extern unless they are static
+	storage := s.Storage
+	if storage == "extern" {
+		storage = ""
+	}
+
+	err = env.Register(identifier, &Symbol{
+		Kind:    kind,
+		Storage: storage,
+		Type:    symbolType,
 	})
 
 	if err != nil {
@@ -95,12 +314,12 @@ func analyzeFunctionDefinition(s *FunctionDefinition, env *Env) []error {
 			Type: symbolType,
 		})
 
-		for _, p := range s.Parameters {
+		for i, p := range s.Parameters {
 			parameter, ok := p.(*ParameterDeclaration)
 
 			if ok {
 				identifier := findIdentifierExpression(parameter.Identifier)
-				argType := composeType(parameter.Identifier, BasicType{Name: parameter.TypeName})
+				argType := argTypes[i]
 
 				err := paramEnv.Register(identifier, &Symbol{
 					Kind: "parm",
@@ -124,15 +343,120 @@ func analyzeFunctionDefinition(s *FunctionDefinition, env *Env) []error {
 
 func analyzeDeclaration(s *Declaration, env *Env) []error {
 	errs := []error{}
+
+	baseType, err := resolveType(s.VarType, env)
+	if err != nil {
+		return append(errs, SemanticError{
+			Pos: s.Pos(),
+			Err: err,
+		})
+	}
+
+	for _, declarator := range s.Declarators {
+		valueType := baseType
+		if declarator.IsFunctionPointer {
+			valueType, err = functionTypeOf(baseType, declarator.ParameterTypes, env)
+			if err != nil {
+				errs = append(errs, SemanticError{
+					Pos: declarator.Pos(),
+					Err: err,
+				})
+			}
+		}
+
+		symbolType := arrayOf(composeType(declarator.Identifier, valueType), declarator.Sizes)
+
+		identifier := findIdentifierExpression(declarator.Identifier)
+		err := env.Register(identifier, &Symbol{
+			Kind:    "var",
+			Storage: s.Storage,
+			Type:    symbolType,
+		})
+
+		if err != nil {
+			errs = append(errs, SemanticError{
+				Pos: declarator.Pos(),
+				Err: err,
+			})
+		}
+
+		// an extern variable is allocated where it is defined
+		// struct members are checked by analyzeStructDeclaration
+		if isIncomplete(symbolType) && s.Storage != "extern" && env.Table["#members"] == nil {
+			errs = append(errs, SemanticError{
+				Pos: declarator.Pos(),
+				Err: fmt.Errorf("variable `%s` has incomplete type `%v`", identifier.Name, symbolType),
+			})
+		}
+
+		if declarator.Initializer == nil {
+			continue
+		}
+
+		if s.Storage == "extern" {
+			errs = append(errs, SemanticError{
+				Pos: declarator.Pos(),
+				Err: fmt.Errorf("extern variable `%s` cannot be initialized", identifier.Name),
+			})
+			continue
+		}
+
+		errs = append(errs, analyzeInitializer(declarator, symbolType, env, s.Storage == "static")...)
+	}
+
+	return errs
+}
+
+// analyzeTypedefDeclaration registers each declarator as an alias of its type
+func analyzeTypedefDeclaration(s *TypedefDeclaration, env *Env) []error {
+	errs := []error{}
+	if s.Definition != nil {
+		errs = append(errs, analyzeStatement(s.Definition, env)...)
+	}
+
+	baseType, err := resolveType(s.VarType, env)
+	if err != nil {
+		return append(errs, SemanticError{
+			Pos: s.Pos(),
+			Err: err,
+		})
+	}
+
 	for _, declarator := range s.Declarators {
-		symbolType := composeType(declarator.Identifier, BasicType{Name: s.VarType})
-		if declarator.Size > 0 {
-			symbolType = ArrayType{Value: symbolType, Size: declarator.Size}
+		valueType := baseType
+		if declarator.IsFunctionPointer {
+			valueType, err = functionTypeOf(baseType, declarator.ParameterTypes, env)
+			if err != nil {
+				errs = append(errs, SemanticError{
+					Pos: declarator.Pos(),
+					Err: err,
+				})
+			}
 		}
 
 		identifier := findIdentifierExpression(declarator.Identifier)
+		if declarator.Initializer != nil {
+			errs = append(errs, SemanticError{
+				Pos: declarator.Pos(),
+				Err: fmt.Errorf("typedef `%s` cannot be initialized", identifier.Name),
+			})
+		}
+
+		symbolType := withAlias(arrayOf(composeType(declarator.Identifier, valueType), declarator.Sizes), identifier.Name)
+		if found := env.Table[identifier.Name]; found != nil && found.Kind == "typedef" {
+			// a typedef may be repeated with the same type
+			if !isSameType(found.Type, symbolType) {
+				errs = append(errs, SemanticError{
+					Pos: declarator.Pos(),
+					Err: fmt.Errorf("typedef redefinition with different types (`%s` vs `%s`)", typeString(symbolType), typeString(found.Type)),
+				})
+			}
+			identifier.Symbol = found
+			continue
+		}
+
 		err := env.Register(identifier, &Symbol{
-			Kind: "var",
+			Kind: "typedef",
 			Type: symbolType,
 		})
 
@@ -147,6 +471,328 @@ func analyzeDeclaration(s *Declaration, env *Env) []error {
 	return errs
 }
 
+// analyzeInitializer checks the shape of the initializer of declarator
+// globals and static locals must be initialized with constants since they are placed in the data section
+func analyzeInitializer(declarator *Declarator, symbolType SymbolType, env *Env, isStatic bool) []error {
+	var errs []error
+
+	name := findIdentifierExpression(declarator.Identifier).Name
+	values, err := flattenInitializer(name, symbolType, declarator.Initializer)
+	if err != nil {
+		return []error{err}
+	}
+
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+
+		valueErrs := analyzeExpression(value, env)
+		errs = append(errs, valueErrs...)
+
+		if len(valueErrs) == 0 && env.Level == 0 && !isConstantInitializer(value) {
+			errs = append(errs, SemanticError{
+				Pos: value.Pos(),
+				Err: fmt.Errorf("initializer of global variable `%s` must be constant", name),
+			})
+		} else if len(valueErrs) == 0 && isStatic && !isConstantInitializer(value) {
+			errs = append(errs, SemanticError{
+				Pos: value.Pos(),
+				Err: fmt.Errorf("initializer of static variable `%s` must be constant", name),
+			})
+		}
+	}
+
+	return errs
+}
+
+// flattenInitializer returns the initial values of the scalars in symbolType in memory order
+// values which are not given are nil (zero)
+func flattenInitializer(name string, symbolType SymbolType, initializer Expression) ([]Expression, error) {
+	list, isList := initializer.(*InitializerList)
+	arrayType, isArray := symbolType.(ArrayType)
+
+	if !isArray {
+		if isList {
+			return nil, SemanticError{
+				Pos: initializer.Pos(),
+				Err: fmt.Errorf("`%s` is initialized with a brace list for `%v`", name, symbolType),
+			}
+		}
+
+		return []Expression{initializer}, nil
+	}
+
+	if !isList {
+		return nil, SemanticError{
+			Pos: initializer.Pos(),
+			Err: fmt.Errorf("array `%s` must be initialized with a brace list", name),
+		}
+	}
+
+	if len(list.Values) > arrayType.Size {
+		return nil, SemanticError{
+			Pos: list.Pos(),
+			Err: fmt.Errorf("too many initializers for `%s`", name),
+		}
+	}
+
+	var values []Expression
+	for i := 0; i < arrayType.Size; i++ {
+		if i >= len(list.Values) {
+			values = append(values, make([]Expression, scalarCount(arrayType.Value))...)
+			continue
+		}
+
+		elementValues, err := flattenInitializer(name, arrayType.Value, list.Values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, elementValues...)
+	}
+
+	return values, nil
+}
+
+func isConstantInitializer(value Expression) bool {
+	if _, isString := value.(*StringExpression); isString {
+		return true
+	}
+
+	isConstant, _ := evaluateConstant(value)
+	return isConstant
+}
+
+func analyzeStructDeclaration(s *StructDeclaration, env *Env) []error {
+	errs := []error{}
+
+	// Register the type before its members so that they can point to the struct itself
+	// a struct used before in this scope is completed by the declaration
+	if found := env.Table["struct "+s.Name]; found != nil && found.Kind == "struct" && !found.Type.(*StructType).IsComplete() {
+		s.Type = found.Type.(*StructType)
+	} else {
+		s.Type = &StructType{Name: s.Name}
+		err := env.Add(&Symbol{
+			Name: "struct " + s.Name,
+			Kind: "struct",
+			Type: s.Type,
+		})
+
+		if err != nil {
+			errs = append(errs, SemanticError{
+				Pos: s.Pos(),
+				Err: err,
+			})
+		}
+	}
+
+	// Members are registered to their own scope to check duplicate names
+	// struct types used by the members are declared out of it
+	memberEnv := env.CreateChild()
+	memberEnv.Add(&Symbol{Name: "#members"})
+	for _, member := range s.Members {
+		declaration, ok := member.(*Declaration)
+		if !ok {
+			errs = append(errs, SemanticError{
+				Pos: member.Pos(),
+				Err: errors.New("nested struct declaration is not supported"),
+			})
+			continue
+		}
+
+		errs = append(errs, analyzeDeclaration(declaration, memberEnv)...)
+
+		for _, declarator := range declaration.Declarators {
+			if declarator.Initializer != nil {
+				errs = append(errs, SemanticError{
+					Pos: declarator.Pos(),
+					Err: errors.New("struct member cannot have an initializer"),
+				})
+			}
+		}
+
+		for _, declarator := range declaration.Declarators {
+			identifier := findIdentifierExpression(declarator.Identifier)
+			if identifier.Symbol == nil {
+				continue
+			}
+
+			memberType := identifier.Symbol.Type
+			if memberType.ByteSize() == 0 || isIncomplete(memberType) {
+				errs = append(errs, SemanticError{
+					Pos: declarator.Pos(),
+					Err: fmt.Errorf("member `%v` has incomplete type `%v`", identifier.Name, memberType),
+				})
+				continue
+			}
+
+			s.Type.AddField(identifier.Name, memberType)
+		}
+	}
+
+	s.Type.complete = true
+	return errs
+}
+
+// analyzeEnumDeclaration registers the enumerators of s as int constants
+// an enumerator without a value is the previous one plus 1, starting from 0
+func analyzeEnumDeclaration(s *EnumDeclaration, env *Env) []error {
+	errs := []error{}
+
+	if s.Name != "" {
+		err := env.Add(&Symbol{
+			Name: "enum " + s.Name,
+			Kind: "enum",
+			Type: Int(),
+		})
+
+		if err != nil {
+			errs = append(errs, SemanticError{
+				Pos: s.Pos(),
+				Err: err,
+			})
+		}
+	}
+
+	next := 0
+	for _, enumerator := range s.Enumerators {
+		if enumerator.Value != nil {
+			valueErrs := analyzeExpression(enumerator.Value, env)
+			errs = append(errs, valueErrs...)
+
+			isConstant, value := evaluateConstant(enumerator.Value)
+			if len(valueErrs) == 0 && !isConstant {
+				errs = append(errs, SemanticError{
+					Pos: enumerator.Value.Pos(),
+					Err: fmt.Errorf("value of enumerator `%s` must be constant", enumerator.Identifier.Name),
+				})
+			}
+
+			next = value
+		}
+
+		err := env.Register(enumerator.Identifier, &Symbol{
+			Kind:  "const",
+			Type:  Int(),
+			Value: next,
+		})
+
+		if err != nil {
+			errs = append(errs, SemanticError{
+				Pos: enumerator.Identifier.Pos(),
+				Err: err,
+			})
+		}
+
+		next++
+	}
+
+	return errs
+}
+
+// parameterType returns the type of parameter
+// array parameters are pointers to their elements
+func parameterType(parameter *ParameterDeclaration, baseType SymbolType) SymbolType {
+	// the outermost size may be omitted, and a typedef of an array decays as well
+	symbolType := arrayOf(composeType(parameter.Identifier, baseType), parameter.Sizes)
+	if arrayType, isArray := symbolType.(ArrayType); isArray {
+		return Pointer(arrayType.Value)
+	}
+
+	return symbolType
+}
+
+// resolveType returns the type which a type name refers to
+// tagEnv returns the scope where a struct used in env is declared, which is out of struct members
+func tagEnv(env *Env) *Env {
+	for env.Table["#members"] != nil {
+		env = env.Parent
+	}
+
+	return env
+}
+
+func resolveType(name string, env *Env) (SymbolType, error) {
+	if strings.HasPrefix(name, "const ") {
+		t, err := resolveType(strings.TrimPrefix(name, "const "), env)
+		if err != nil {
+			return t, err
+		}
+
+		return constOf(t)
+	}
+
+	// enum types are int
+	if strings.HasPrefix(name, "enum ") {
+		symbol := env.Get(name)
+		if symbol == nil || symbol.Kind != "enum" {
+			return Int(), fmt.Errorf("unknown type `%s`", name)
+		}
+
+		return symbol.Type, nil
+	}
+
+	if strings.HasPrefix(name, "struct ") {
+		symbol := env.Get(name)
+		if symbol == nil {
+			// a struct used before its declaration is incomplete, which pointers can refer to
+			symbol = &Symbol{Name: name, Kind: "struct", Type: &StructType{Name: strings.TrimPrefix(name, "struct ")}}
+			tagEnv(env).Add(symbol)
+		}
+
+		if symbol.Kind != "struct" {
+			return BasicType{Name: name}, fmt.Errorf("unknown type `%s`", name)
+		}
+
+		return symbol.Type, nil
+	}
+
+	if symbol := env.GetTypedef(name); symbol != nil {
+		return symbol.Type, nil
+	}
+
+	// only int can be unsigned
+	if strings.HasPrefix(name, "unsigned ") && name != "unsigned int" {
+		return Unsigned(), fmt.Errorf("unknown type `%s`", name)
+	}
+
+	return BasicType{Name: name}, nil
+}
+
+// functionTypeOf returns the function type of a function pointer declarator like `int (*f)(int, char*)`
+func functionTypeOf(returnType SymbolType, parameterTypes []string, env *Env) (SymbolType, error) {
+	args := []SymbolType{}
+
+	// int (*f)(void) takes no arguments
+	if len(parameterTypes) == 1 && parameterTypes[0] == "void" {
+		parameterTypes = nil
+	}
+
+	for _, name := range parameterTypes {
+		t, err := resolveTypeName(name, env)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, t)
+	}
+
+	return FunctionType{Return: returnType, Args: args}, nil
+}
+
+// resolveTypeName resolves a type name with pointers like `struct node*`
+func resolveTypeName(name string, env *Env) (SymbolType, error) {
+	baseName := strings.TrimRight(name, "*")
+
+	t, err := resolveType(baseName, env)
+	for i := len(baseName); i < len(name); i++ {
+		t = Pointer(t)
+	}
+
+	return t, err
+}
+
 func analyzeCompoundStatement(s *CompoundStatement, env *Env) []error {
 	var errs []error
 	newEnv := env.CreateChild()
@@ -174,7 +820,13 @@ func analyzeExpression(expression Expression, env *Env) []error {
 				Err: fmt.Errorf("reference error: `%v` is undefined", e.Name),
 			})
 		} else {
-			if !symbol.IsVariable() {
+			if symbol.IsFunction() && isSystemCall(e.Name) {
+				// builtins are not real functions
+				errs = append(errs, SemanticError{
+					Pos: e.Pos(),
+					Err: fmt.Errorf("builtin `%v` cannot be used as a value", e.Name),
+				})
+			} else if !symbol.IsVariable() && !symbol.IsFunction() && !symbol.IsConstant() {
 				errs = append(errs, SemanticError{
 					Pos: e.Pos(),
 					Err: fmt.Errorf("`%v` is not variable", e.Name),
@@ -190,45 +842,34 @@ func analyzeExpression(expression Expression, env *Env) []error {
 		}
 
 	case *BinaryExpression:
-		errs = append(errs, analyzeExpression(e.Left, env)...)
+		leftErrs := analyzeExpression(e.Left, env)
+		errs = append(errs, leftErrs...)
 		errs = append(errs, analyzeExpression(e.Right, env)...)
 
-		if e.Operator == "=" {
-			leftIsAssignable := true
-
-			switch left := e.Left.(type) {
-			case *IdentifierExpression:
-				expressionErrs := analyzeExpression(left, env)
-				errs = append(errs, expressionErrs...)
-
-				if len(errs) == 0 {
-					_, isArrayType := left.Symbol.Type.(ArrayType)
-					if !left.Symbol.IsVariable() || isArrayType {
-						leftIsAssignable = false
-					}
-				}
-
-			case *UnaryExpression:
-				if left.Operator != "*" {
-					leftIsAssignable = false
-				}
+		if e.IsAssignment() {
+			errs = append(errs, analyzeAssignable(e.Left, len(leftErrs) == 0)...)
+		}
 
-			default:
-				leftIsAssignable = false
-			}
+	case *ConditionalExpression:
+		errs = analyzeExpression(e.Condition, env)
+		errs = append(errs, analyzeExpression(e.TrueValue, env)...)
+		errs = append(errs, analyzeExpression(e.FalseValue, env)...)
 
-			if !leftIsAssignable {
-				errs = append(errs, SemanticError{
-					Pos: e.Left.Pos(),
-					Err: errors.New("expression is not assignable"),
-				})
-			}
-		}
+	case *PostfixExpression:
+		errs = analyzeExpression(e.Value, env)
+		errs = append(errs, analyzeAssignable(e.Value, len(errs) == 0)...)
 
 	case *UnaryExpression:
 		if e.Operator == "&" {
 			switch v := e.Value.(type) {
-			case *IdentifierExpression:
+			case *IdentifierExpression, *MemberExpression, *ArrayReferenceExpression:
+			case *UnaryExpression:
+				if v.Operator != "*" {
+					errs = append(errs, SemanticError{
+						Pos: v.Pos(),
+						Err: errors.New("the operand of `&` must be on memory"),
+					})
+				}
 			default:
 				errs = append(errs, SemanticError{
 					Pos: v.Pos(),
@@ -239,12 +880,42 @@ func analyzeExpression(expression Expression, env *Env) []error {
 
 		return append(errs, analyzeExpression(e.Value, env)...)
 
+	case *CastExpression:
+		t, err := resolveTypeName(e.TypeName, env)
+		if err != nil {
+			errs = append(errs, SemanticError{Pos: e.Pos(), Err: err})
+		}
+
+		e.Type = t
+		errs = append(errs, analyzeExpression(e.Value, env)...)
+
+	case *SizeofExpression:
+		if e.Value != nil {
+			return analyzeExpression(e.Value, env)
+		}
+
+		t, err := resolveTypeName(e.TypeName, env)
+		if err != nil {
+			errs = append(errs, SemanticError{Pos: e.Pos(), Err: err})
+		}
+
+		e.Type = t
+
+	case *MemberExpression:
+		errs = analyzeExpression(e.Target, env)
+
 	case *ArrayReferenceExpression:
 		errs = append(errs, analyzeExpression(e.Target, env)...)
 		errs = append(errs, analyzeExpression(e.Index, env)...)
 
 	case *FunctionCallExpression:
-		identifier := findIdentifierExpression(e.Identifier)
+		identifier, isIdentifier := e.Identifier.(*IdentifierExpression)
+		if !isIdentifier {
+			// (*f)(a), s.f(a): the type of the callee is checked by CheckType
+			errs = analyzeExpression(e.Identifier, env)
+			return append(errs, analyzeExpression(e.Argument, env)...)
+		}
+
 		symbol := env.Get(identifier.Name)
 		if symbol == nil {
 			return []error{
@@ -255,7 +926,8 @@ func analyzeExpression(expression Expression, env *Env) []error {
 			}
 		}
 
-		if !(symbol.Kind == "fun" || symbol.Kind == "proto") {
+		// a variable is called indirectly if it is a function pointer
+		if !(symbol.IsFunction() || symbol.IsVariable()) {
 			return []error{
 				SemanticError{
 					Pos: identifier.Pos(),
@@ -271,6 +943,58 @@ func analyzeExpression(expression Expression, env *Env) []error {
 	return errs
 }
 
+// analyzeAssignable checks that left can be the target of an assignment
+// read-only is checked only if left is resolved, because its type is unknown otherwise
+func analyzeAssignable(left Expression, resolved bool) []error {
+	leftIsAssignable := true
+
+	switch l := left.(type) {
+	case *IdentifierExpression:
+		// undefined identifiers are reported by analyzeExpression
+		if l.Symbol != nil {
+			_, isArrayType := l.Symbol.Type.(ArrayType)
+			if !l.Symbol.IsVariable() || isArrayType {
+				leftIsAssignable = false
+			}
+		}
+
+	case *UnaryExpression:
+		if l.Operator != "*" {
+			leftIsAssignable = false
+		}
+
+	case *MemberExpression:
+
+	default:
+		leftIsAssignable = false
+	}
+
+	if !leftIsAssignable {
+		return []error{
+			SemanticError{
+				Pos: left.Pos(),
+				Err: errors.New("expression is not assignable"),
+			},
+		}
+	}
+
+	if !resolved {
+		return nil
+	}
+
+	// the type is unknown if left has type errors, which CheckType reports
+	if t, err := typeOfExpression(left); err == nil && isConst(t) {
+		return []error{
+			SemanticError{
+				Pos: left.Pos(),
+				Err: fmt.Errorf("cannot assign to `%v`, which is read-only", typeString(t)),
+			},
+		}
+	}
+
+	return nil
+}
+
 func findIdentifierExpression(expression Expression) *IdentifierExpression {
 	switch e := expression.(type) {
 	case *IdentifierExpression:
@@ -282,11 +1006,16 @@ func findIdentifierExpression(expression Expression) *IdentifierExpression {
 	panic("IdentifierExpression not found")
 }
 
+// composeType returns the type of a declarator like `* const *p` whose base type is symbolType
+// the first `*` is the innermost pointer, so `int * const *p` is a pointer to `int* const`
 func composeType(identifier Expression, symbolType SymbolType) SymbolType {
 	switch e := identifier.(type) {
 	case *UnaryExpression:
-		if e.Operator == "*" {
-			return PointerType{Value: composeType(e.Value, symbolType)}
+		switch e.Operator {
+		case "*":
+			return composeType(e.Value, PointerType{Value: symbolType})
+		case "* const":
+			return composeType(e.Value, PointerType{Value: symbolType, Const: true})
 		}
 	case *IdentifierExpression:
 		return symbolType
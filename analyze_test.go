@@ -2,6 +2,7 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -162,8 +163,21 @@ func TestAnalyzeExpression(t *testing.T) {
 	{
 		env := &Env{}
 		env.Add(&Symbol{Name: "foo", Kind: "fun"})
+		env.Add(&Symbol{Name: "print", Kind: "proto"})
+		env.Add(&Symbol{Name: "bar", Kind: "struct"})
 
+		// a function name is a pointer to the function
 		errs := analyzeExpression(&IdentifierExpression{Name: "foo"}, env)
+		if len(errs) > 0 {
+			t.Errorf("expect no error, got %v", errs)
+		}
+
+		errs = analyzeExpression(&IdentifierExpression{Name: "print"}, env)
+		if len(errs) != 1 {
+			t.Errorf("expect builtin error, got %v", errs)
+		}
+
+		errs = analyzeExpression(&IdentifierExpression{Name: "bar"}, env)
 		if len(errs) != 1 {
 			t.Errorf("expect not variable error, got %v", errs)
 		}
@@ -186,7 +200,7 @@ func TestAnalyzeExpression(t *testing.T) {
 			t.Errorf("expect no error, but got: %v", errs)
 		}
 
-		env.Table["foo"] = &Symbol{Name: "foo", Kind: "var"}
+		env.Table["foo"] = &Symbol{Name: "foo", Kind: "struct"}
 		errs = analyzeExpression(e, env)
 
 		if len(errs) != 1 {
@@ -227,6 +241,90 @@ func TestAnalyzeExpression(t *testing.T) {
 	}
 }
 
+func TestAnalyzeBreakAndContinue(t *testing.T) {
+	{
+		statements, _ := Parse(`
+			int main() {
+				int i;
+				for (i = 0; i < 10; i = i + 1) {
+					if (i == 2) continue;
+					while (1) break;
+				}
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 0 {
+			t.Errorf("expect no error, but got: %v", errs)
+		}
+	}
+
+	{
+		statements, _ := Parse(`
+			int main() {
+				break;
+				if (1) {
+					continue;
+				}
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 2 {
+			t.Errorf("expect 2 errors of break and continue outside loops, but got: %v", errs)
+		}
+	}
+}
+
+func TestAnalyzeSwitch(t *testing.T) {
+	{
+		statements, _ := Parse(`
+			int main() {
+				int a;
+				switch (a) {
+				case 1:
+				case 'a':
+					break;
+				default:
+					a = 0;
+				}
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 0 {
+			t.Errorf("expect no error, but got: %v", errs)
+		}
+
+		s := mainStatements(statements)[0].(*SwitchStatement)
+		if len(s.Cases) != 3 {
+			t.Errorf("expect 3 cases, got %v", s.Cases)
+		}
+	}
+
+	{
+		statements, _ := Parse(`
+			int main() {
+				int a;
+				switch (a) {
+				case 1:
+				case 2 - 1:
+				case a:
+				default:
+				default:
+					break;
+				}
+				case 3: ;
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 4 {
+			t.Errorf("expect duplicate case, non-constant case, multiple default and case outside switch errors, but got: %v", errs)
+		}
+	}
+}
+
 func TestAnalyzeArrayAssignment(t *testing.T) {
 	statements, _ := Parse(`
 		int main() {
@@ -240,3 +338,409 @@ func TestAnalyzeArrayAssignment(t *testing.T) {
 		t.Errorf("should have 1 error: %v", errs)
 	}
 }
+
+func TestAnalyzeStruct(t *testing.T) {
+	{
+		statements, _ := Parse(`
+			struct node {
+				int value;
+				struct node *next;
+			};
+
+			struct node *head;
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 0 {
+			t.Errorf("expect no error, but got: %v", errs)
+		}
+
+		structType := statements[0].(*StructDeclaration).Type
+		next := structType.Field("next")
+		if !(next != nil && next.Offset == 4 && next.Type.String() == "struct node*") {
+			t.Errorf("expect `next` to be struct node* at offset 4, got %+v", next)
+		}
+	}
+
+	{
+		statements, _ := Parse(`
+			struct node {
+				int value;
+				int value;
+				struct node self;
+			};
+
+			struct tree t;
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 3 {
+			t.Errorf("expect duplicate member, incomplete member and incomplete variable errors, but got: %v", errs)
+		}
+	}
+
+	{
+		// pointers can refer to a struct before its declaration
+		statements, _ := Parse(`
+			struct a { struct b *pb; };
+			struct b { struct a *pa; int value; };
+			struct handle *open();
+			extern struct handle opened;
+
+			int main() {
+				struct a x;
+				struct b y;
+				struct handle *h;
+				x.pb = &y;
+				y.pa = &x;
+				h = open();
+				return x.pb->value;
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 0 {
+			t.Errorf("expect no error, but got: %v", errs)
+		}
+
+		a := statements[0].(*StructDeclaration).Type
+		b := statements[1].(*StructDeclaration).Type
+		if pb := a.Field("pb"); pb == nil || pb.Type.(PointerType).Value != SymbolType(b) {
+			t.Errorf("expect `pb` to point to the declared struct b, got %+v", pb)
+		}
+	}
+
+	sources := []string{
+		"struct t *p; int main() { return p->x; }",
+		"struct t *p; int main() { return sizeof(*p); }",
+		"struct t *p; int main() { return sizeof(struct t); }",
+		"int main() { struct t v; }",
+		"struct t *p; int main() { struct t v[2]; }",
+	}
+
+	for _, src := range sources {
+		statements, _ := Parse(src)
+		for i, statement := range statements {
+			statements[i] = Walk(statement)
+		}
+
+		if errs := Analyze(statements, &Env{}); len(errs) == 0 && CheckType(statements) == nil {
+			t.Errorf("expect error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestAnalyzeIncrement(t *testing.T) {
+	statements, _ := Parse(`
+		int main() {
+			int a, data[2];
+			a++;
+			a += 2;
+			*data -= 1;
+			data++;
+			data *= 2;
+			1++;
+			(a + 1) += 1;
+		}
+	`)
+
+	errs := Analyze(statements, &Env{})
+	if len(errs) != 4 {
+		t.Errorf("expect 4 not assignable errors, but got: %v", errs)
+	}
+}
+
+func TestAnalyzeInitializer(t *testing.T) {
+	{
+		statements, _ := Parse(`
+			int a = 1 + 2, b[3] = {1, 2}, c;
+			char *s = "hello";
+
+			int main() {
+				int x = a, y[2] = {x, x + 1};
+			}
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 0 {
+			t.Errorf("expect no error, but got: %v", errs)
+		}
+	}
+
+	{
+		statements, _ := Parse(`
+			int a = 1;
+			int b = a;
+			int c[2] = {1, 2, 3};
+			int d[2] = 1;
+			int e = {1};
+			int f[2] = {{1}};
+		`)
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 5 {
+			t.Errorf("expect non-constant, too many, not a list, not an array and nested list errors, but got: %v", errs)
+		}
+	}
+}
+
+func TestAnalyzeMultiDimensionalArray(t *testing.T) {
+	statements, _ := Parse(`
+		int m[2][3] = {{1, 2}, {3}};
+		int f(int a[], int b[][3]);
+	`)
+
+	errs := Analyze(statements, &Env{})
+	if len(errs) != 0 {
+		t.Errorf("expect no error, but got: %v", errs)
+	}
+
+	m := findIdentifierExpression(statements[0].(*Declaration).Declarators[0].Identifier).Symbol
+	if m.Type.String() != "int[2][3]" {
+		t.Errorf("expect int[2][3], got %v", m.Type)
+	}
+
+	f := findIdentifierExpression(statements[1].(*FunctionDefinition).Identifier).Symbol
+	if f.Type.String() != "(int*, int[3]*) -> int" {
+		t.Errorf("expect array parameters to be pointers, got %v", f.Type)
+	}
+
+	values := initializerValues(statements[0].(*Declaration).Declarators[0])
+	if !(len(values) == 6 && values[2] == nil && values[3] != nil && values[4] == nil) {
+		t.Errorf("expect flattened values [1 2 0 3 0 0], got %v", values)
+	}
+}
+
+func TestAnalyzeEnum(t *testing.T) {
+	statements, err := Parse(`
+    enum color { RED, GREEN = 5, BLUE, LAST = BLUE * 2, };
+    enum { NONE = -1 };
+
+    int main() {
+      enum { A = LAST + 1, B = -A };
+      enum color c;
+      switch (c) {
+        case RED: case A: return GREEN;
+      }
+    }
+  `)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &Env{}
+	if errs := Analyze(statements, env); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	expected := map[string]int{"RED": 0, "GREEN": 5, "BLUE": 6, "LAST": 12, "NONE": -1}
+	for name, value := range expected {
+		symbol := env.Get(name)
+		if symbol == nil || !symbol.IsConstant() || symbol.Value != value {
+			t.Errorf("expect %v to be %v, got %+v", name, value, symbol)
+		}
+	}
+
+	sources := []string{
+		"enum { A, B }; int A;",
+		"enum { A, A };",
+		"int main() { int x; enum { A = x }; }",
+		"enum { A }; int main() { A = 1; }",
+		"int main() { enum shape s; }",
+	}
+
+	for _, src := range sources {
+		statements, _ := Parse(src)
+		if errs := Analyze(statements, &Env{}); len(errs) == 0 {
+			t.Errorf("expect error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestAnalyzeTypedef(t *testing.T) {
+	statements, err := Parse(`
+    typedef int number, *number_ptr;
+    number_ptr p;
+
+    int main() {
+      typedef char byte;
+      byte b;
+    }
+
+    number n;
+  `)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &Env{}
+	if errs := Analyze(statements, env); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if symbol := env.Get("number_ptr"); symbol == nil || symbol.Kind != "typedef" || symbol.Type.String() != "int*" {
+		t.Errorf("expect number_ptr to be an alias of int*, got %+v", symbol)
+	}
+
+	if symbol := env.Get("p"); symbol == nil || !symbol.IsVariable() || aliasOf(symbol.Type) != "number_ptr" {
+		t.Errorf("expect p to be number_ptr, got %+v", symbol)
+	}
+
+	if env.Get("byte") != nil {
+		t.Error("expect byte to be local to main")
+	}
+
+	sources := []string{
+		"typedef int number = 1;",
+		"typedef struct shape shape_t; shape_t s;",
+		"int number; typedef int number;",
+		"typedef int number; int number;",
+	}
+
+	for _, src := range sources {
+		statements, _ := Parse(src)
+		if errs := Analyze(statements, &Env{}); len(errs) == 0 {
+			t.Errorf("expect error for `%v`, but nil", src)
+		}
+	}
+
+	// identifiers and nested typedefs shadow a typedef name
+	shadowing := []string{
+		"typedef int T; int main() { int T; T = 1; return T; }",
+		"typedef int T; int main() { T x; { typedef char T; T c; } x = 1; }",
+		"typedef int T; int f(int T) { return T; } T g;",
+		"typedef int T; int main() { enum { T = 1 }; return T; }",
+		"typedef int T; struct s { int T; }; int main() { struct s v; T x; v.T = 1; }",
+		"typedef int T; typedef int T; T x;",
+		"typedef int T; struct s { T T; T v; }; T x;",
+		"typedef struct node { int v; struct node *next; } Node, *NodePtr; Node n; NodePtr p; struct node m;",
+		"typedef enum { RED, GREEN } Color; typedef enum shape { CIRCLE } Shape; Color c; Shape s; enum shape t; int x = GREEN + CIRCLE;",
+	}
+
+	for _, src := range shadowing {
+		statements, err := Parse(src)
+		if err != nil {
+			t.Errorf("%v: %v", src, err)
+			continue
+		}
+
+		if errs := Analyze(statements, &Env{}); len(errs) > 0 {
+			t.Errorf("%v: %v", src, errs)
+		}
+	}
+
+	statements, _ = Parse("typedef int T; typedef char T;")
+	errs := Analyze(statements, &Env{})
+	if !(len(errs) == 1 && strings.Contains(errs[0].Error(), "`T (aka char)` vs `T (aka int)`")) {
+		t.Errorf("expect typedef redefinition error, got %v", errs)
+	}
+}
+
+func TestAnalyzeStorageClass(t *testing.T) {
+	statements, err := Parse(`
+    static int f();
+    int f() { static int n = 1 + 2; extern int g; return n + g; }
+    extern int g;
+    int g = 4;
+  `)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &Env{}
+	if errs := Analyze(statements, env); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if symbol := env.Get("f"); symbol == nil || symbol.Storage != "static" {
+		t.Errorf("expect f to keep internal linkage, got %+v", symbol)
+	}
+
+	sources := []string{
+		"int main() { int a; static int b = a; }",
+		"extern int a = 1;",
+		"int main() { int a; extern int a; }",
+	}
+
+	for _, src := range sources {
+		statements, _ := Parse(src)
+		if errs := Analyze(statements, &Env{}); len(errs) == 0 {
+			t.Errorf("expect error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestAnalyzeConst(t *testing.T) {
+	statements, err := Parse(`
+    const int limit = 3;
+    struct point { int x; };
+
+    int norm(const struct point *point) {
+      return (*point).x;
+    }
+
+    int main() {
+      const int n = limit + 1;
+      int x, * const p = &x;
+      const int *q;
+      struct point origin;
+      *p = n;
+      q = p;
+      origin.x = norm(&origin);
+      return *q;
+    }
+  `)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := Analyze(statements, &Env{}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	sources := []string{
+		"const int limit = 3; int main() { limit = 4; }",
+		"int main() { const int n = 1; n += 2; }",
+		"int main() { const int n = 1; n++; }",
+		"int main() { int x; const int *p; p = &x; *p = 1; }",
+		"int main() { int x, * const p = &x; p = 0; }",
+		"struct point { int x; }; int main() { const struct point p; p.x = 1; }",
+		"struct point { int x; }; int main() { struct point a; const struct point b; b = a; }",
+		"struct point { int x; }; int main() { const struct point *p; (*p).x = 1; }",
+	}
+
+	for _, src := range sources {
+		statements, _ := Parse(src)
+		if errs := Analyze(statements, &Env{}); len(errs) == 0 {
+			t.Errorf("expect error for `%v`, but nil", src)
+		}
+	}
+
+	// a[i] is walked into *(a + i), which is at the position of a
+	positions := map[string]string{
+		"const int t[2] = {1, 2};\nint main() {\n  t[0] = 5;\n}": "3:4",
+		"int main() {\n  const char *p;\n  p[1] = 2;\n}":         "3:4",
+		"int main() { const int a[2][2]; a[0][1] = 1; }":         "1:34",
+	}
+
+	for src, expected := range positions {
+		statements, _ := Parse(src)
+		for i, statement := range statements {
+			statements[i] = Walk(statement)
+		}
+
+		errs := Analyze(statements, &Env{})
+		if len(errs) != 1 {
+			t.Errorf("expect an error for `%v`, got %v", src, errs)
+			continue
+		}
+
+		if e, ok := errs[0].(SemanticError); !ok || formatPosition(e.Pos) != expected {
+			t.Errorf("expect an error at %v for `%v`, got %#v", expected, src, errs[0])
+		}
+	}
+}
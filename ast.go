@@ -26,12 +26,24 @@ func (e *ExpressionList) Pos() scanner.Position {
 	return first.Pos()
 }
 
+// NumberExpression is an integer constant, and Value is in decimal
+// IsUnsigned is true if the constant does not fit in int
 type NumberExpression struct {
+	pos        scanner.Position
+	Value      string
+	IsUnsigned bool
+}
+
+func (e *NumberExpression) Pos() scanner.Position { return e.pos }
+
+// StringExpression is a string literal
+// Value is the content between the quotes with escape sequences replaced
+type StringExpression struct {
 	pos   scanner.Position
 	Value string
 }
 
-func (e *NumberExpression) Pos() scanner.Position { return e.pos }
+func (e *StringExpression) Pos() scanner.Position { return e.pos }
 
 type IdentifierExpression struct {
 	pos    scanner.Position
@@ -60,11 +72,30 @@ func (e *BinaryExpression) Pos() scanner.Position {
 }
 
 func (e *BinaryExpression) IsAssignment() bool {
-	return e.Operator == "="
+	return e.Operator == "=" || e.IsCompoundAssignment()
+}
+
+// IsCompoundAssignment reports whether e is `a op= b`
+func (e *BinaryExpression) IsCompoundAssignment() bool {
+	switch e.Operator {
+	case "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=":
+		return true
+	}
+
+	return false
 }
 
 func (e *BinaryExpression) IsArithmetic() bool {
-	return e.Operator == "+" || e.Operator == "-" || e.Operator == "/" || e.Operator == "*"
+	return e.Operator == "+" || e.Operator == "-" || e.Operator == "/" || e.Operator == "*" || e.Operator == "%"
+}
+
+func (e *BinaryExpression) IsBitwise() bool {
+	switch e.Operator {
+	case "&", "|", "^", "<<", ">>":
+		return true
+	}
+
+	return false
 }
 
 func (e *BinaryExpression) IsLogical() bool {
@@ -80,14 +111,15 @@ func (e *BinaryExpression) IsEqual() bool {
 	return false
 }
 
+// FunctionCallExpression is `Identifier(Argument)`
+// Identifier is a function name, or an expression of a function pointer for indirect calls
 type FunctionCallExpression struct {
 	Identifier Expression
 	Argument   Expression
 }
 
 func (e *FunctionCallExpression) Pos() scanner.Position {
-	identifier := e.Identifier.(*IdentifierExpression)
-	return identifier.Pos()
+	return e.Identifier.Pos()
 }
 
 type ArrayReferenceExpression struct {
@@ -99,6 +131,69 @@ func (e *ArrayReferenceExpression) Pos() scanner.Position {
 	return e.Target.Pos()
 }
 
+// InitializerList is `{ Values }` in a declaration
+type InitializerList struct {
+	pos    scanner.Position
+	Values []Expression
+}
+
+func (e *InitializerList) Pos() scanner.Position { return e.pos }
+
+// ConditionalExpression is `Condition ? TrueValue : FalseValue`
+type ConditionalExpression struct {
+	Condition  Expression
+	TrueValue  Expression
+	FalseValue Expression
+}
+
+func (e *ConditionalExpression) Pos() scanner.Position {
+	return e.Condition.Pos()
+}
+
+// CastExpression is `(TypeName) Value`
+// Type is resolved by Analyze
+type CastExpression struct {
+	pos      scanner.Position
+	TypeName string
+	Value    Expression
+	Type     SymbolType
+}
+
+func (e *CastExpression) Pos() scanner.Position { return e.pos }
+
+// SizeofExpression is `sizeof Value` or `sizeof(TypeName)`
+// Type is resolved by Analyze for the type name form
+type SizeofExpression struct {
+	pos      scanner.Position
+	TypeName string
+	Value    Expression
+	Type     SymbolType
+}
+
+func (e *SizeofExpression) Pos() scanner.Position { return e.pos }
+
+// PostfixExpression is `Value++` or `Value--`
+type PostfixExpression struct {
+	Operator string
+	Value    Expression
+}
+
+func (e *PostfixExpression) Pos() scanner.Position {
+	return e.Value.Pos()
+}
+
+// MemberExpression is `Target.Member` or `Target->Member`
+// `p->m` is converted to `(*p).m` by Walk
+type MemberExpression struct {
+	Target   Expression
+	Operator string
+	Member   string
+}
+
+func (e *MemberExpression) Pos() scanner.Position {
+	return e.Target.Pos()
+}
+
 type PointerExpression struct {
 	pos   scanner.Position
 	Value Expression
@@ -106,9 +201,14 @@ type PointerExpression struct {
 
 func (e *PointerExpression) Pos() scanner.Position { return e.pos }
 
+// Declarator is `Identifier[Sizes[0]][Sizes[1]]... = Initializer`
+// or `(*Identifier)(ParameterTypes) = Initializer` if IsFunctionPointer
 type Declarator struct {
-	Identifier Expression
-	Size       int
+	Identifier        Expression
+	Sizes             []int
+	ParameterTypes    []string
+	IsFunctionPointer bool
+	Initializer       Expression
 }
 
 func (e *Declarator) Pos() scanner.Position {
@@ -123,16 +223,57 @@ func (e *Declarator) Pos() scanner.Position {
 	panic("unexpected identifier")
 }
 
+// Declaration is `Storage VarType Declarators;`
+// Storage is "static", "extern" or empty
 type Declaration struct {
 	pos         scanner.Position
+	Storage     string
 	VarType     string
 	Declarators []*Declarator
 }
 
 func (e *Declaration) Pos() scanner.Position { return e.pos }
 
+// StructDeclaration defines `struct Name { Members }`
+type StructDeclaration struct {
+	pos     scanner.Position
+	Name    string
+	Members []Statement
+	Type    *StructType
+}
+
+func (e *StructDeclaration) Pos() scanner.Position { return e.pos }
+
+// TypedefDeclaration defines the names of Declarators as aliases of their types
+// Definition is the struct or enum declared in its type specifier, or nil
+type TypedefDeclaration struct {
+	pos         scanner.Position
+	VarType     string
+	Declarators []*Declarator
+	Definition  Statement
+}
+
+func (e *TypedefDeclaration) Pos() scanner.Position { return e.pos }
+
+// EnumDeclaration defines `enum Name { Enumerators }`, and Name may be empty
+type EnumDeclaration struct {
+	pos         scanner.Position
+	Name        string
+	Enumerators []*Enumerator
+}
+
+func (e *EnumDeclaration) Pos() scanner.Position { return e.pos }
+
+// Enumerator is `Identifier = Value` in an enum, and Value is nil if omitted
+type Enumerator struct {
+	Identifier *IdentifierExpression
+	Value      Expression
+}
+
+// FunctionDefinition is a prototype if Statement is nil
 type FunctionDefinition struct {
 	pos        scanner.Position
+	Storage    string
 	TypeName   string
 	Identifier Expression
 	Parameters []Expression
@@ -173,14 +314,28 @@ func (e *IfStatement) Statements() []Statement {
 	return []Statement{e.TrueStatement, e.FalseStatement}
 }
 
+// WhileStatement runs Statement and then Loop while Condition is true
+// Loop is the step of a desugared for statement, `continue` jumps to it
 type WhileStatement struct {
 	pos       scanner.Position
 	Condition Expression
 	Statement Statement
+	Loop      Statement
 }
 
 func (e *WhileStatement) Pos() scanner.Position { return e.pos }
 func (e *WhileStatement) Statements() []Statement {
+	return []Statement{e.Statement, e.Loop}
+}
+
+type DoWhileStatement struct {
+	pos       scanner.Position
+	Statement Statement
+	Condition Expression
+}
+
+func (e *DoWhileStatement) Pos() scanner.Position { return e.pos }
+func (e *DoWhileStatement) Statements() []Statement {
 	return []Statement{e.Statement}
 }
 
@@ -194,6 +349,37 @@ type ForStatement struct {
 
 func (e *ForStatement) Pos() scanner.Position { return e.pos }
 
+// SwitchStatement jumps to one of Cases, which are found in Statement by Analyze
+type SwitchStatement struct {
+	pos       scanner.Position
+	Value     Expression
+	Statement Statement
+	Cases     []*CaseStatement
+}
+
+func (e *SwitchStatement) Pos() scanner.Position { return e.pos }
+
+// CaseStatement is `case Value: Statement`, or `default: Statement` if Value is nil
+type CaseStatement struct {
+	pos       scanner.Position
+	Value     Expression
+	Statement Statement
+}
+
+func (e *CaseStatement) Pos() scanner.Position { return e.pos }
+
+type BreakStatement struct {
+	pos scanner.Position
+}
+
+func (e *BreakStatement) Pos() scanner.Position { return e.pos }
+
+type ContinueStatement struct {
+	pos scanner.Position
+}
+
+func (e *ContinueStatement) Pos() scanner.Position { return e.pos }
+
 type ReturnStatement struct {
 	pos            scanner.Position
 	Value          Expression
@@ -202,10 +388,16 @@ type ReturnStatement struct {
 
 func (e *ReturnStatement) Pos() scanner.Position { return e.pos }
 
+// ParameterDeclaration is `TypeName Identifier`
+// `int m[][4]` has Sizes [0, 4] and is passed as a pointer to int[4]
+// `int (*f)(int)` has ParameterTypes like Declarator
 type ParameterDeclaration struct {
-	pos        scanner.Position
-	TypeName   string
-	Identifier Expression
+	pos               scanner.Position
+	TypeName          string
+	Identifier        Expression
+	Sizes             []int
+	ParameterTypes    []string
+	IsFunctionPointer bool
 }
 
 func (e *ParameterDeclaration) Pos() scanner.Position { return e.pos }
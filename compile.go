@@ -2,14 +2,18 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
+// initial value of $gp
+const globalPointer = 0x10008000
+
 func CalculateOffset(ir *IRProgram) {
 	globalOffset := 0
 	// global vars
 	for _, d := range ir.Declarations {
-		size := d.Var.Type.ByteSize()
+		size := alignedSize(d.Var.Type)
 		globalOffset -= size
 		d.Var.Offset = globalOffset
 	}
@@ -24,7 +28,7 @@ func calculateOffsetFunction(ir *IRFunctionDefinition) {
 
 	for i := len(ir.Parameters) - 1; i >= 0; i-- {
 		p := ir.Parameters[i]
-		size := p.Var.Type.ByteSize()
+		size := alignedSize(p.Var.Type)
 
 		// arg 4 => 4($fp), arg 5 => 8($fp)
 		if i >= 4 {
@@ -46,7 +50,7 @@ func calculateOffsetStatement(statement IRStatement, base int) int {
 	switch s := statement.(type) {
 	case *IRCompoundStatement:
 		for _, d := range s.Declarations {
-			size := d.Var.Type.ByteSize()
+			size := alignedSize(d.Var.Type)
 			d.Var.Offset = offset - (size - 4)
 			offset -= size
 		}
@@ -64,15 +68,123 @@ func calculateOffsetStatement(statement IRStatement, base int) int {
 	return minOffset
 }
 
+// alignedSize returns the size of a variable slot
+// every variable is word aligned, so a char occupies the first byte of a word
+func alignedSize(symbolType SymbolType) int {
+	return (symbolType.ByteSize() + 3) / 4 * 4
+}
+
+// jump tables of switch statements, which are placed after string literals
+// so that they are not written over the globals placed at their addresses
+var jumpTables []string
+
 // Compile takes ir program as input and returns mips code
 func Compile(program *IRProgram) string {
 	CalculateOffset(program)
+	jumpTables = nil
+
+	text := ""
+	for _, f := range program.Functions {
+		text += "\n" + strings.Join(compileFunction(f), "\n") + "\n"
+	}
 
 	code := ""
 	code += ".data\n"
-	code += ".text\n.globl main\n"
-	for _, f := range program.Functions {
-		code += "\n" + strings.Join(compileFunction(f), "\n") + "\n"
+	for _, s := range program.Strings {
+		code += compileString(s)
+	}
+	for _, table := range jumpTables {
+		code += table + "\n"
+	}
+	code += compileGlobalData(program.Declarations)
+	code += ".text\n"
+	code += text
+
+	return code
+}
+
+// compileString emits a string literal by .asciiz
+// it is emitted by .byte if it has a control character which .asciiz cannot escape
+func compileString(s *IRStringDeclaration) string {
+	var escaped strings.Builder
+
+	for i := 0; i < len(s.Value); i++ {
+		ch := s.Value[i]
+
+		switch {
+		case ch == '\n':
+			escaped.WriteString(`\n`)
+
+		case ch == '\t':
+			escaped.WriteString(`\t`)
+
+		case ch == '"' || ch == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteByte(ch)
+
+		case ch < 0x20 || ch == 0x7f:
+			var values []string
+			for _, b := range []byte(s.Value) {
+				values = append(values, strconv.Itoa(int(b)))
+			}
+
+			return fmt.Sprintf("%s: .byte %s, 0\n", s.Label, strings.Join(values, ", "))
+
+		default:
+			escaped.WriteByte(ch)
+		}
+	}
+
+	return fmt.Sprintf("%s: .asciiz \"%s\"\n", s.Label, escaped.String())
+}
+
+// compileGlobalData emits the initial values of global variables at their address below $gp
+// const globals are emitted by .rdata so that writes to them fail
+// variables with external linkage are labeled by their names
+func compileGlobalData(declarations []*IRVariableDeclaration) string {
+	code := ""
+
+	// the last declared variable has the lowest address
+	for i := len(declarations) - 1; i >= 0; i-- {
+		d := declarations[i]
+		address := globalPointer + d.Var.Offset
+
+		if len(d.Init) == 0 {
+			if d.Var.IsExternal() {
+				code += fmt.Sprintf(".data 0x%08x\n%s:\n", address, d.Var.Label())
+			}
+			continue
+		}
+
+		elementType := scalarType(d.Var.Type)
+
+		directive := ".word"
+		if elementType.ByteSize() == 1 {
+			directive = ".byte"
+		}
+
+		var values []string
+		for _, value := range d.Init {
+			switch v := value.(type) {
+			case *IRNumberExpression:
+				values = append(values, strconv.Itoa(v.Value))
+			case *IRStringExpression:
+				values = append(values, v.Label)
+			}
+		}
+
+		section := ".data"
+		if d.IsReadOnly() {
+			section = ".rdata"
+		}
+
+		label := ""
+		if d.Var.IsExternal() {
+			label = d.Var.Label() + ": "
+		}
+
+		code += fmt.Sprintf("%s 0x%08x\n", section, address)
+		code += fmt.Sprintf("%s%s %s\n", label, directive, strings.Join(values, ", "))
 	}
 
 	return code
@@ -82,6 +194,12 @@ func compileFunction(function *IRFunctionDefinition) []string {
 	size := function.VarSize + 4*2 // arguments + local vars + $ra + $fp
 
 	var code []string
+
+	// static functions have internal linkage
+	if function.Var.Storage != "static" {
+		code = append(code, fmt.Sprintf(".globl %s", function.Var.Name))
+	}
+
 	code = append(
 		code,
 		fmt.Sprintf("%s:", function.Var.Name),
@@ -127,24 +245,18 @@ func compileStatement(statement IRStatement, function *IRFunctionDefinition) []s
 		code = append(code, sw("$t0", s.Var))
 
 	case *IRCallStatement:
-		for i := len(s.Vars) - 1; i >= 0; i-- {
-			v := s.Vars[i]
-
-			if i >= 4 {
-				code = append(code, lw("$t0", v))
-				code = append(code,
-					"addi $sp, $sp, -4",
-					fmt.Sprintf("sw %s, 0($sp)", "$t0"),
-				)
-			} else {
-				code = append(code, lw(fmt.Sprintf("$a%d", i), v))
-			}
-		}
-
+		code = append(code, passArguments(s.Vars)...)
 		code = append(code, fmt.Sprintf("jal %s", s.Func.Name))
-		if len(s.Vars) > 4 {
-			code = append(code, fmt.Sprintf("addi $sp, $sp, %d", 4*(len(s.Vars)-4)))
-		}
+		code = append(code, popArguments(s.Vars)...)
+		code = append(code, sw("$v0", s.Dest))
+
+	case *IRIndirectCallStatement:
+		code = append(code, passArguments(s.Vars)...)
+		code = append(code,
+			lw("$t0", s.Target),
+			"jalr $t0",
+		)
+		code = append(code, popArguments(s.Vars)...)
 		code = append(code, sw("$v0", s.Dest))
 
 	case *IRReturnStatement:
@@ -162,13 +274,13 @@ func compileStatement(statement IRStatement, function *IRFunctionDefinition) []s
 		return []string{
 			lw("$t0", s.Src),
 			lw("$t1", s.Dest),
-			"sw $t0, 0($t1)",
+			fmt.Sprintf("%s $t0, 0($t1)", storeInst(s.Size)),
 		}
 
 	case *IRReadStatement:
 		return []string{
 			lw("$t0", s.Src),
-			"lw $t1, 0($t0)",
+			fmt.Sprintf("%s $t1, 0($t0)", loadInst(s.Size)),
 			sw("$t1", s.Dest),
 		}
 
@@ -211,6 +323,23 @@ func compileStatement(statement IRStatement, function *IRFunctionDefinition) []s
 	case *IRGotoStatement:
 		code = append(code, jmp(s.Label))
 
+	case *IRJumpTableStatement:
+		// 0 <= index < len(table) is checked by an unsigned comparison
+		table := label("jump_table")
+
+		code = append(code,
+			lw("$t0", s.Var),
+			fmt.Sprintf("sltiu $t1, $t0, %d", len(s.Labels)),
+			fmt.Sprintf("beq $t1, $zero, %s", s.Default),
+			"sll $t0, $t0, 2",
+			fmt.Sprintf("la $t1, %s", table),
+			"add $t1, $t1, $t0",
+			"lw $t0, 0($t1)",
+			"jr $t0",
+		)
+
+		jumpTables = append(jumpTables, fmt.Sprintf("%s: .word %s", table, strings.Join(s.Labels, ", ")))
+
 	case *IRSystemCallStatement:
 		switch s.Name {
 		case "print":
@@ -225,6 +354,12 @@ func compileStatement(statement IRStatement, function *IRFunctionDefinition) []s
 				lw("$a0", s.Var),
 				"syscall",
 			}
+		case "print_string":
+			return []string{
+				"li $v0, 4",
+				lw("$a0", s.Var),
+				"syscall",
+			}
 
 		default:
 			panic("invalid system call: " + s.Name)
@@ -265,16 +400,23 @@ func assignExpression(register string, expression IRExpression) []string {
 		// *(a + 4)
 		_, isArrayType := e.Var.Type.(ArrayType)
 		if isArrayType {
-			return []string{
-				fmt.Sprintf("addi %s, %s, %d", register, e.Var.AddressPointer(), e.Var.Offset),
-			}
+			return []string{la(register, e.Var)}
 		}
 
 		return append(code, lw(register, e.Var))
 
 	case *IRAddressExpression:
+		if e.Var.IsFunction() {
+			return []string{
+				fmt.Sprintf("la %s, %s", register, e.Var.Name),
+			}
+		}
+
+		return []string{la(register, e.Var)}
+
+	case *IRStringExpression:
 		return []string{
-			fmt.Sprintf("addi %s, %s, %d", register, e.Var.AddressPointer(), e.Var.Offset),
+			fmt.Sprintf("la %s, %s", register, e.Label),
 		}
 	}
 
@@ -290,6 +432,12 @@ func assignBinaryOperation(register string, operator string, left string, right
 	}
 
 	switch operator {
+	case "!":
+		// !a <=> a < 1 (unsigned)
+		return []string{
+			fmt.Sprintf("sltiu %s, %s, 1", register, left),
+		}
+
 	case "==":
 		falseLabel := label("beq_true")
 		endLabel := label("beq_end")
@@ -316,47 +464,121 @@ func assignBinaryOperation(register string, operator string, left string, right
 			endLabel + ":",
 		}
 
-	case ">":
-		// a > b <=> (a <= b) < 1
-		return append(assignBinaryOperation(register, "<=", left, right),
-			fmt.Sprintf("slti %s, %s, 1", register, register),
-		)
+	case ">", ">u":
+		// a > b <=> b < a
+		return assignBinaryOperation(register, "<"+operator[1:], right, left)
 
-	case "<=":
-		// a <= b <=> a - 1 < b
-		return []string{
-			fmt.Sprintf("addi %s, %s, -1", left, left),
-			fmt.Sprintf("slt %s, %s, %s", register, left, right),
-		}
+	case "<=", "<=u":
+		// a <= b <=> (b < a) < 1, which does not overflow unlike a - 1 < b
+		return append(assignBinaryOperation(register, "<"+operator[2:], right, left),
+			fmt.Sprintf("sltiu %s, %s, 1", register, register),
+		)
 
-	case ">=":
-		// a >= b <=> b <= a
-		return assignBinaryOperation(register, "<=", right, left)
+	case ">=", ">=u":
+		// a >= b <=> (a < b) < 1
+		return append(assignBinaryOperation(register, "<"+operator[2:], left, right),
+			fmt.Sprintf("sltiu %s, %s, 1", register, register),
+		)
 	}
 
 	panic("unimplemented operator: " + operator)
 }
 
 var operatorToInst = map[string]string{
-	"+": "add",
-	"-": "sub",
-	"*": "mul",
-	"/": "div",
-	"<": "slt",
+	"+":   "add",
+	"-":   "sub",
+	"*":   "mul",
+	"/":   "div",
+	"%":   "rem",
+	"<":   "slt",
+	"/u":  "divu",
+	"%u":  "remu",
+	"<u":  "sltu",
+	">>u": "srlv",
+	"&":   "and",
+	"|":   "or",
+	"^":   "xor",
+	"nor": "nor",
+	"<<":  "sllv",
+	">>":  "srav",
 }
 
 func jmp(label string) string {
 	return fmt.Sprintf("j %s", label)
 }
 
+// passArguments sets the first 4 arguments to $a0-$a3 and pushes the rest to the stack
+func passArguments(vars []*Symbol) []string {
+	var code []string
+
+	for i := len(vars) - 1; i >= 0; i-- {
+		v := vars[i]
+
+		if i >= 4 {
+			code = append(code, lw("$t0", v))
+			code = append(code,
+				"addi $sp, $sp, -4",
+				fmt.Sprintf("sw %s, 0($sp)", "$t0"),
+			)
+		} else {
+			code = append(code, lw(fmt.Sprintf("$a%d", i), v))
+		}
+	}
+
+	return code
+}
+
+// popArguments removes the arguments pushed by passArguments
+func popArguments(vars []*Symbol) []string {
+	if len(vars) > 4 {
+		return []string{fmt.Sprintf("addi $sp, $sp, %d", 4*(len(vars)-4))}
+	}
+
+	return nil
+}
+
 func li(register string, value int) string {
 	return fmt.Sprintf("li %s, %d", register, value)
 }
 
 func lw(register string, src *Symbol) string {
-	return fmt.Sprintf("lw %s, %d(%s)", register, src.Offset, src.AddressPointer())
+	return fmt.Sprintf("%s %s, %s", loadInst(src.Type.ByteSize()), register, memoryOperand(src))
 }
 
 func sw(register string, dest *Symbol) string {
-	return fmt.Sprintf("sw %s, %d(%s)", register, dest.Offset, dest.AddressPointer())
+	return fmt.Sprintf("%s %s, %s", storeInst(dest.Type.ByteSize()), register, memoryOperand(dest))
+}
+
+// la loads the address of the variable symbol
+func la(register string, symbol *Symbol) string {
+	if symbol.IsExternal() {
+		return fmt.Sprintf("la %s, %s", register, symbol.Label())
+	}
+
+	return fmt.Sprintf("addi %s, %s, %d", register, symbol.AddressPointer(), symbol.Offset)
+}
+
+func memoryOperand(symbol *Symbol) string {
+	if symbol.IsExternal() {
+		return symbol.Label()
+	}
+
+	return fmt.Sprintf("%d(%s)", symbol.Offset, symbol.AddressPointer())
+}
+
+// loadInst returns the load instruction for size bytes (a word if 0)
+func loadInst(size int) string {
+	if size == 1 {
+		return "lb"
+	}
+
+	return "lw"
+}
+
+func storeInst(size int) string {
+	if size == 1 {
+		return "sb"
+	}
+
+	return "sw"
 }
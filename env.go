@@ -10,6 +10,9 @@ type Env struct {
 	Level    int
 	Children []*Env
 	Parent   *Env
+
+	// Externs is the variables declared `extern` in blocks, which only the root has
+	Externs map[string]*Symbol
 }
 
 func (env *Env) CreateChild() *Env {
@@ -24,6 +27,29 @@ func (env *Env) Add(symbol *Symbol) error {
 	}
 
 	name := symbol.Name
+	if symbol.Kind == "var" && (env.Level == 0 || symbol.Storage == "extern") {
+		if linked := env.linkedVariable(name); linked != nil {
+			if err := linked.link(symbol); err != nil {
+				return err
+			}
+
+			env.Table[name] = linked
+			return nil
+		}
+
+		if env.Level > 0 && env.Table[name] == nil {
+			// the variable of a block extern is at the file scope, so Level stays 0
+			root := env.root()
+			if root.Externs == nil {
+				root.Externs = map[string]*Symbol{}
+			}
+
+			root.Externs[name] = symbol
+			env.Table[name] = symbol
+			return nil
+		}
+	}
+
 	found := env.Table[name]
 	if found != nil {
 		if symbol.IsVariable() {
@@ -41,6 +67,15 @@ func (env *Env) Add(symbol *Symbol) error {
 			if symbol.Type.String() != functionType.String() {
 				return fmt.Errorf("prototype mismatch error: function `%v`: `%v` != `%v`", name, functionType, symbol.Type)
 			}
+
+			if symbol.Storage == "static" && found.Storage != "static" {
+				return fmt.Errorf("static declaration of `%s` follows non-static declaration", name)
+			}
+
+			// internal linkage is kept by the later declarations
+			if found.Storage == "static" {
+				symbol.Storage = "static"
+			}
 		}
 	}
 
@@ -52,17 +87,85 @@ func (env *Env) Add(symbol *Symbol) error {
 	return nil
 }
 
+// linkedVariable returns the previous declaration of the variable which has external or internal linkage
+// a block extern and a file scope variable of the same name refer to one object
+func (env *Env) linkedVariable(name string) *Symbol {
+	if found := env.Table[name]; found != nil {
+		if found.Kind == "var" && (found.Level == 0 || found.Storage == "extern") {
+			return found
+		}
+
+		return nil
+	}
+
+	root := env.root()
+	if found := root.Table[name]; found != nil && env.Level > 0 {
+		if found.Kind == "var" {
+			return found
+		}
+
+		return nil
+	}
+
+	return root.Externs[name]
+}
+
+// link merges the declaration symbol into s
+func (s *Symbol) link(symbol *Symbol) error {
+	if symbol.Storage != "extern" && s.Storage != "extern" {
+		return fmt.Errorf("`%s` is already defined", s.Name)
+	}
+
+	if !isSameType(s.Type, symbol.Type) {
+		return fmt.Errorf("conflicting types for `%s`: `%v` and `%v`", s.Name, s.Type, symbol.Type)
+	}
+
+	if symbol.Storage == "static" && s.Storage != "static" {
+		return fmt.Errorf("static declaration of `%s` follows non-static declaration", s.Name)
+	}
+
+	// the definition gives the storage
+	if symbol.Storage != "extern" {
+		s.Storage = symbol.Storage
+	}
+
+	return nil
+}
+
+func (env *Env) root() *Env {
+	if env.Parent != nil {
+		return env.Parent.root()
+	}
+
+	return env
+}
+
 func (env *Env) Register(identifier *IdentifierExpression, symbol *Symbol) error {
 	symbol.Name = identifier.Name
 	err := env.Add(symbol)
 
 	if err == nil {
-		identifier.Symbol = symbol
+		// a redeclaration of a linked variable refers to the previous symbol
+		identifier.Symbol = env.Table[identifier.Name]
 	}
 
 	return err
 }
 
+// GetTypedef returns the typedef name in the innermost scope
+// the lexer doesn't take a name hidden by an identifier as a type, and struct members don't hide it
+func (env *Env) GetTypedef(name string) *Symbol {
+	if symbol := env.Table[name]; symbol != nil && symbol.Kind == "typedef" {
+		return symbol
+	}
+
+	if env.Parent != nil {
+		return env.Parent.GetTypedef(name)
+	}
+
+	return nil
+}
+
 func (env *Env) Get(name string) *Symbol {
 	symbol := env.Table[name]
 
@@ -78,19 +181,43 @@ func (env *Env) Get(name string) *Symbol {
 }
 
 type Symbol struct {
-	Name   string
-	Level  int
-	Kind   string
-	Type   SymbolType
-	Offset int
+	Name    string
+	Level   int
+	Kind    string
+	Storage string // "static", "extern" or empty
+	Type    SymbolType
+	Offset  int
+	Value   int // of an enumerator
 }
 
 func (symbol *Symbol) IsVariable() bool {
 	return symbol.Kind == "var" || symbol.Kind == "parm"
 }
 
+// IsConstant reports whether symbol is an enumerator, which has no storage
+func (symbol *Symbol) IsConstant() bool {
+	return symbol.Kind == "const"
+}
+
+func (symbol *Symbol) IsFunction() bool {
+	return symbol.Kind == "fun" || symbol.Kind == "proto"
+}
+
+// IsGlobal reports whether symbol is placed in the data section, which includes static locals
 func (symbol *Symbol) IsGlobal() bool {
-	return symbol.Level == 0
+	return symbol.Level == 0 || symbol.Storage == "static"
+}
+
+// IsExternal reports whether symbol is a variable with external linkage, which is addressed by its label
+// so that an extern variable is resolved when the program is assembled
+func (symbol *Symbol) IsExternal() bool {
+	return symbol.Kind == "var" && symbol.Level == 0 && symbol.Storage != "static"
+}
+
+// Label returns the label of a variable with external linkage
+// identifiers have no `.`, so it never collides with functions or the labels made by the compiler
+func (symbol *Symbol) Label() string {
+	return "var." + symbol.Name
 }
 
 func (symbol *Symbol) AddressPointer() string {
@@ -110,3 +237,12 @@ type SemanticError struct {
 func (e SemanticError) Error() string {
 	return e.Err.Error()
 }
+
+// formatPosition formats pos as `file:line:column`, or `line:column` if it has no file name
+func formatPosition(pos scanner.Position) string {
+	if pos.Filename == "" {
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+}
@@ -92,6 +92,50 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestAddLinkage(t *testing.T) {
+	{
+		env := &Env{}
+		declaration := &Symbol{Name: "x", Kind: "var", Storage: "extern", Type: Int()}
+		env.Add(declaration)
+
+		block := env.CreateChild()
+		block.Add(&Symbol{Name: "x", Kind: "var", Storage: "extern", Type: Int()})
+
+		if err := env.Add(&Symbol{Name: "x", Kind: "var", Type: Int()}); err != nil {
+			t.Errorf("expect the definition of extern `x`, but got \"%v\"", err)
+		}
+
+		if !(env.Get("x") == declaration && block.Get("x") == declaration && declaration.Storage == "") {
+			t.Errorf("expect every `x` to be one defined symbol, got %v and %v", env.Get("x"), block.Get("x"))
+		}
+	}
+
+	{
+		env := &Env{}
+		block := env.CreateChild()
+		block.Add(&Symbol{Name: "y", Kind: "var", Storage: "extern", Type: Int()})
+
+		if y := env.Externs["y"]; y == nil || !y.IsExternal() {
+			t.Errorf("expect `y` to be an external variable, got %v", y)
+		}
+	}
+
+	errorCases := [][]*Symbol{
+		{{Name: "x", Kind: "var", Storage: "extern", Type: Int()}, {Name: "x", Kind: "var", Type: Char()}},
+		{{Name: "x", Kind: "var", Storage: "extern", Type: Int()}, {Name: "x", Kind: "var", Storage: "static", Type: Int()}},
+		{{Name: "x", Kind: "var", Storage: "static", Type: Int()}, {Name: "x", Kind: "var", Type: Int()}},
+		{{Name: "f", Kind: "proto", Type: FunctionType{Return: Int()}}, {Name: "f", Kind: "fun", Storage: "static", Type: FunctionType{Return: Int()}}},
+	}
+
+	for _, symbols := range errorCases {
+		env := &Env{}
+		env.Add(symbols[0])
+		if err := env.Add(symbols[1]); err == nil {
+			t.Errorf("expect error for %+v after %+v, but nil", symbols[1], symbols[0])
+		}
+	}
+}
+
 func TestRegister(t *testing.T) {
 	env := &Env{}
 	identifier := &IdentifierExpression{Name: "foo"}
@@ -0,0 +1,35 @@
+int popcount(int x) {
+  int count;
+  count = 0;
+
+  while (x != 0) {
+    count = count + (x & 1);
+    x = (x >> 1) & 2147483647;
+  }
+
+  return count;
+}
+
+int main() {
+  int a, b;
+  a = 12;
+  b = 10;
+
+  print(17 % 5);
+  print(-17 % 5);
+  putchar(' ');
+  print(a & b);
+  print(a | b);
+  print(a ^ b);
+  putchar(' ');
+  print(~a);
+  print(1 << 4);
+  print(-32 >> 2);
+  putchar(' ');
+  print(1 + 2 << 3);
+  print(a & b == 8);
+  print(a | b ^ 6);
+  putchar(' ');
+  print(popcount(~0));
+  print(popcount(b << b));
+}
@@ -0,0 +1,29 @@
+int main() {
+  int i, j, sum;
+
+  sum = 0;
+  for (i = 0; i < 10; i = i + 1) {
+    if (i == 3) {
+      continue;
+    }
+
+    if (i == 6) {
+      break;
+    }
+
+    sum = sum + i;
+  }
+  print(sum);
+
+  i = 0;
+  while (1) {
+    i = i + 1;
+    if (i < 5) continue;
+
+    for (j = 0; ; j = j + 1) {
+      if (j == i) break;
+    }
+    break;
+  }
+  print(i + j);
+}
@@ -0,0 +1,38 @@
+char bytes[8];
+
+int checksum(void *data, int n) {
+  char *p;
+  int sum;
+  p = (char*) data;
+  sum = 0;
+  while (n--) sum = sum + (*p++ & 255);
+  return sum;
+}
+
+int main() {
+  int a, *p;
+  char c;
+
+  a = 300;
+  c = (char) a;
+  print(c);
+  putchar(' ');
+
+  print((char) 200);
+  print((int) (char) 127);
+  putchar(' ');
+
+  c = (char) 0 - 1;
+  print((int) c);
+  print(c * 2);
+  putchar(' ');
+
+  p = (int*) bytes;
+  *p = 67305985;
+  print(checksum(bytes, 4));
+  print((int) (p + 1) - (int) p);
+  putchar(' ');
+
+  (void) checksum(p, 0);
+  putchar((char) (256 + 'A'));
+}
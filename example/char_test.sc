@@ -0,0 +1,33 @@
+char text[6];
+
+void puts(char *s) {
+  while (*s != 0) {
+    putchar(*s);
+    s = s + 1;
+  }
+}
+
+char next(char c) {
+  return c + 1;
+}
+
+int main() {
+  char c;
+  char *p;
+
+  text[0] = 'h';
+  text[1] = 'e';
+  text[2] = 'l';
+  text[3] = 'l';
+  text[4] = 'o';
+  text[5] = 0;
+  puts(text);
+
+  p = text + 4;
+  *p = next('n');
+  puts(p - 1);
+
+  c = 300;
+  print(c);
+  print(next(127));
+}
@@ -0,0 +1,42 @@
+const int days[12] = {31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31};
+const char hex[16] = {'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'};
+const int base = 16;
+
+int day_of_year(int month, int day) {
+  int i, total;
+  total = day;
+  for (i = 0; i < month - 1; i++) total = total + days[i];
+  return total;
+}
+
+void print_hex(int n) {
+  if (n >= base) print_hex(n / base);
+  putchar(hex[n % base]);
+}
+
+int sum(const int *values, int n) {
+  int i, s;
+  s = 0;
+  for (i = 0; i < n; i++) s = s + values[i];
+  return s;
+}
+
+int main() {
+  int x;
+  int * const p = &x;
+  const char *message;
+
+  print(day_of_year(3, 1));
+  print(sum(days, 12));
+  putchar(' ');
+
+  print_hex(255);
+  print_hex(base * 3 + days[1]);
+  putchar(' ');
+
+  *p = 7;
+  print(x);
+
+  message = " done";
+  print_string(message);
+}
@@ -0,0 +1,20 @@
+int main() {
+  int i, n;
+
+  i = 10;
+  do {
+    print(i);
+    i = i + 1;
+  } while (i < 5);
+
+  i = 0;
+  n = 0;
+  do {
+    i = i + 1;
+    if (i == 2) continue;
+    if (i == 5) break;
+    n = n + i;
+  } while (1);
+
+  print(n);
+}
@@ -0,0 +1,58 @@
+enum op { PUSH, ADD, MUL, PRINT, HALT = 9 };
+enum { STACK_SIZE = 8, WORD = sizeof(int) };
+
+int program[11] = {PUSH, 6, PUSH, 7, MUL, PUSH, 8, ADD, PRINT, HALT, 0};
+int size = STACK_SIZE * WORD;
+
+int run(int *code) {
+  int stack[8], sp, pc, steps;
+  enum op instruction;
+  sp = 0;
+  pc = 0;
+  steps = 0;
+
+  while (1) {
+    instruction = code[pc++];
+    steps++;
+
+    switch (instruction) {
+      case PUSH:
+        stack[sp++] = code[pc++];
+        break;
+      case ADD:
+        sp--;
+        stack[sp - 1] = stack[sp - 1] + stack[sp];
+        break;
+      case MUL:
+        sp--;
+        stack[sp - 1] = stack[sp - 1] * stack[sp];
+        break;
+      case PRINT:
+        print(stack[sp - 1]);
+        break;
+      case HALT:
+        return steps;
+    }
+  }
+}
+
+int main() {
+  enum state { OFF, ON };
+  enum state s;
+
+  print(run(program));
+  putchar(' ');
+
+  s = ON;
+  print(s);
+  print(HALT + 1);
+  print(size);
+  putchar(' ');
+
+  {
+    int ON;
+    ON = 7;
+    print(ON);
+  }
+  print(ON);
+}
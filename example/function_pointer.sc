@@ -0,0 +1,50 @@
+struct counter {
+  int value;
+  int (*step)(int);
+};
+
+int add(int a, int b) { return a + b; }
+int sub(int a, int b) { return a - b; }
+int mul(int a, int b) { return a * b; }
+int twice(int a) { return a * 2; }
+int sum6(int a, int b, int c, int d, int e, int f) { return a + b + c + d + e + f; }
+
+int fold(int *p, int n, int init, int (*f)(int, int)) {
+  int i;
+  for (i = 0; i < n; i++) init = f(init, p[i]);
+  return init;
+}
+
+int main() {
+  int (*ops[3])(int, int);
+  int (*op)(int, int), (**pop)(int, int);
+  int (*many)(int, int, int, int, int, int) = sum6;
+  int a[4] = {1, 2, 3, 4}, i;
+  struct counter c, *pc;
+
+  ops[0] = add;
+  ops[1] = sub;
+  ops[2] = &mul;
+  for (i = 0; i < 3; i++) print(ops[i](7, 3));
+  putchar(' ');
+
+  print(fold(a, 4, 0, add));
+  print(fold(a, 4, 1, mul));
+  putchar(' ');
+
+  op = sub;
+  pop = &op;
+  print((**pop)(10, 4));
+  print(op == sub);
+  print(op != ops[0]);
+  putchar(' ');
+
+  c.value = 3;
+  c.step = twice;
+  pc = &c;
+  pc->value = pc->step(pc->step(pc->value));
+  print(c.value);
+  putchar(' ');
+
+  print(many(1, 2, 3, 4, 5, 6));
+}
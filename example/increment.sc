@@ -0,0 +1,51 @@
+int calls;
+
+int next() {
+  calls++;
+  return 1;
+}
+
+int main() {
+  int i, sum, data[4], *p;
+  char c;
+
+  sum = 0;
+  for (i = 0; i < 4; i++) {
+    data[i] = i * 10;
+    sum += i;
+  }
+  print(sum);
+  putchar(' ');
+
+  p = data;
+  *(p + next()) += 5;
+  print(data[1]);
+  print(calls);
+  putchar(' ');
+
+  p++;
+  print(*p++);
+  print(*p);
+  print(*--p);
+  putchar(' ');
+
+  data[3] -= 1;
+  data[3] *= 2;
+  data[3] /= 4;
+  data[3] %= 10;
+  data[3] <<= 2;
+  data[3] |= 3;
+  print(data[3]);
+  putchar(' ');
+
+  i = 5;
+  print(i++ + ++i);
+  print(i--);
+  print(--i);
+  putchar(' ');
+
+  c = 127;
+  print(c++);
+  print(c);
+  print(++c);
+}
@@ -0,0 +1,52 @@
+int count = 3;
+int primes[5] = {2, 3, 5, 7};
+char greeting[6] = {'h', 'e', 'l', 'l', 'o'};
+char *message = "world";
+int negative = -4 * 2, zero;
+
+void puts(char *s) {
+  while (*s) {
+    putchar(*s++);
+  }
+}
+
+int sum(int *data, int size) {
+  int i, total = 0;
+
+  for (i = 0; i < size; i++) {
+    total += data[i];
+  }
+
+  return total;
+}
+
+int main() {
+  int i;
+  int local = count * 2, copy = local + 1;
+  int squares[4] = {0, 1, 4, 9};
+  char tail[3] = {'!'};
+
+  print(count);
+  print(sum(primes, 5));
+  print(primes[4]);
+  putchar(' ');
+
+  puts(greeting);
+  putchar(' ');
+  puts(message);
+  puts(tail);
+  putchar(' ');
+
+  print(negative + zero);
+  print(local);
+  print(copy);
+  print(sum(squares, 4));
+  putchar(' ');
+
+  for (i = 0; i < 3; i++) {
+    int n = i * 10;
+    int fresh[2] = {n};
+    fresh[1] += n;
+    print(fresh[0] + fresh[1]);
+  }
+}
@@ -0,0 +1,31 @@
+char *escapes = "tab\tquote\"backslash\\";
+
+int main() {
+  char *s;
+  int count;
+
+  print(0x1F + 010 + 0b11);
+  putchar(' ');
+  print(0xFFFFFFFF / 0x10);
+  putchar(' ');
+  print(-1 < 0x80000000);
+  print(-1 < 2147483647);
+  putchar(' ');
+
+  count = 0;
+  for (s = escapes; *s; s++) {
+    if (*s == '\t' || *s == '\"' || *s == '\\') {
+      count++;
+    }
+  }
+
+  print(count);
+  print('\0');
+  print('\xff');
+  putchar('\'');
+  putchar('\101');
+  putchar('\x42');
+  print_string("\x43\?");
+
+  return 0;
+}
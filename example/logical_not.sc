@@ -0,0 +1,56 @@
+int find(int *data, int size, int value) {
+  int i;
+
+  for (i = 0; i < size; i = i + 1) {
+    if (!(data[i] - value)) {
+      return i;
+    }
+  }
+
+  return -1;
+}
+
+int main() {
+  int data[4];
+  int found, done, count;
+
+  data[0] = 3;
+  data[1] = 1;
+  data[2] = 4;
+  data[3] = 1;
+
+  found = find(data, 4, 4);
+  if (!(found < 0)) {
+    print(found);
+  }
+
+  if (!find(data, 4, 3)) {
+    print(0);
+  }
+
+  print(!0);
+  print(!7);
+  print(!!7);
+  print(!'a' == 0);
+
+  done = 0;
+  count = 0;
+  while (!done) {
+    count = count + 1;
+    done = count == 3;
+  }
+  print(count);
+
+  do {
+    count = count - 1;
+  } while (!(count == 0));
+  print(count);
+
+  if (count == 0) {
+    putchar('z');
+  }
+
+  if (!count != 0) {
+    putchar('y');
+  }
+}
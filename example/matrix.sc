@@ -0,0 +1,61 @@
+int identity[3][3] = {{1, 0, 0}, {0, 1, 0}, {0, 0, 1}};
+
+void matmul(int a[][3], int b[][3], int c[3][3], int n) {
+  int i, j, k;
+
+  for (i = 0; i < n; i++) {
+    for (j = 0; j < n; j++) {
+      c[i][j] = 0;
+      for (k = 0; k < n; k++) {
+        c[i][j] += a[i][k] * b[k][j];
+      }
+    }
+  }
+}
+
+int trace(int m[][3], int n) {
+  int i, sum = 0;
+
+  for (i = 0; i < n; i++) {
+    sum += m[i][i];
+  }
+
+  return sum;
+}
+
+int sum_row(int *row, int n) {
+  int i, sum = 0;
+
+  for (i = 0; i < n; i++) {
+    sum += row[i];
+  }
+
+  return sum;
+}
+
+int main() {
+  int a[3][3] = {{2, 3, 2}, {1, 4, -1}, {-2, 1, -3}};
+  int b[3][3] = {{-3, 1, 2}, {-2, -4, 2}, {4, 3, 1}};
+  int c[3][3];
+  char grid[2][3][4];
+  int i, j;
+
+  matmul(a, b, c, 3);
+  for (i = 0; i < 3; i++) {
+    for (j = 0; j < 3; j++) {
+      print(c[i][j]);
+      putchar(' ');
+    }
+  }
+
+  matmul(a, identity, c, 3);
+  print(trace(c, 3));
+  print(sum_row(c[1], 3));
+  putchar(' ');
+
+  grid[1][2][3] = 'z';
+  grid[0][0][0] = 'a';
+  putchar(grid[1][2][3]);
+  putchar(**grid[0]);
+  putchar(*(*(*(grid + 1) + 2) + 3));
+}
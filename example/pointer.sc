@@ -0,0 +1,52 @@
+int length(char *s) {
+  char *p;
+  p = s;
+  while (*p) p++;
+  return p - s;
+}
+
+int *find(int *begin, int *end, int value) {
+  int *p;
+  for (p = begin; p < end; p++) {
+    if (*p == value) return p;
+  }
+
+  return 0;
+}
+
+void swap(int **a, int **b) {
+  int *tmp;
+  tmp = *a;
+  *a = *b;
+  *b = tmp;
+}
+
+int main() {
+  int a[5] = {3, 1, 4, 1, 5};
+  int *p, *q, **pp, ***ppp;
+
+  print(length("hello"));
+  putchar(' ');
+
+  p = find(a, a + 5, 4);
+  print(p - a);
+  print(find(a, a + 5, 9) == 0);
+  putchar(' ');
+
+  pp = &p;
+  ppp = &pp;
+  print(***ppp);
+  print(*(**ppp + 1));
+  putchar(' ');
+
+  q = &a[1];
+  swap(&p, &q);
+  print(*p);
+  print(*q);
+  print(p < q);
+  print(q - p);
+  putchar(' ');
+
+  **ppp = 0;
+  print(*pp == 0);
+}
@@ -0,0 +1,10 @@
+#ifndef PREPROCESS_H
+#define PREPROCESS_H
+
+#define SIZE 4
+#define SQUARE(x) ((x) * (x))
+#define MAX(a, b) ((a) > (b) ? (a) : (b))
+
+int sum(int *values, int size);
+
+#endif
@@ -0,0 +1,60 @@
+#include "preprocess.h"
+#include "preprocess.h"
+
+#define LEVEL 2
+#define TWICE(x) (2 * (x))
+#define SUM3(a, b, c) \
+  ((a) + (b) + \
+   (c))
+
+/* sum is declared in preprocess.h
+#define SIZE 8 */
+int sum(int *values, int size) {
+  int i;
+  int total;
+
+  total = 0;
+  for (i = 0; i < size; i++) {
+    total += values[i];
+  }
+
+  return total;
+}
+
+int main() {
+  int values[SIZE];
+  int i;
+
+  for (i = 0; i < SIZE; i++) {
+    values[i] = SQUARE(i + 1);
+  }
+
+  print(sum(values, SIZE));
+  putchar(' ');
+  print(MAX(TWICE(3), SQUARE(2)));
+  print(SUM3(1, TWICE(2), SIZE));
+  putchar(' ');
+
+#if LEVEL > 1 && defined(SIZE)
+  print(1);
+#elif LEVEL
+  print(2);
+#else
+  print(3);
+#endif
+
+#ifdef UNDEFINED
+  print(4);
+#endif
+
+#undef SIZE
+#ifndef SIZE
+  print(5);
+#endif
+
+  putchar(' ');
+  print(__LINE__);
+  print_string(" SIZE");
+
+  return 0;
+}
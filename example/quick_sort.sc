@@ -1,6 +1,14 @@
 void swap(int *p, int *q);
 
-void quick_sort(int *p, int left, int right) {
+int ascending(int a, int b) {
+  return a - b;
+}
+
+int descending(int a, int b) {
+  return b - a;
+}
+
+void quick_sort(int *p, int left, int right, int (*cmp)(int, int)) {
   int i, j, pivot;
 
   if (left >= right) {
@@ -13,11 +21,11 @@ void quick_sort(int *p, int left, int right) {
   j = right;
 
   while (i < j) {
-    while (*(p + i) < pivot) {
+    while (cmp(*(p + i), pivot) < 0) {
       i = i + 1;
     }
 
-    while (pivot < *(p + j)) {
+    while (cmp(pivot, *(p + j)) < 0) {
       j = j - 1;
     }
 
@@ -29,8 +37,8 @@ void quick_sort(int *p, int left, int right) {
     }
   }
 
-  quick_sort(p, left, i - 1);
-  quick_sort(p, j + 1, right);
+  quick_sort(p, left, i - 1, cmp);
+  quick_sort(p, j + 1, right, cmp);
 }
 
 void swap(int *p, int *q) {
@@ -53,7 +61,14 @@ int main() {
   swap(data + 1, data + 5);
   swap(data + 3, data + 7);
 
-  quick_sort(data, 0, size);
+  quick_sort(data, 0, size - 1, ascending);
+
+  for (i = 0; i < size; i = i + 1) {
+    print(data[i]);
+  }
+
+  putchar(' ');
+  quick_sort(data, 0, size - 1, &descending);
 
   for (i = 0; i < size; i = i + 1) {
     print(data[i]);
@@ -0,0 +1,38 @@
+struct point {
+  char tag;
+  int x;
+  int y;
+};
+
+int table[12];
+int size = sizeof table / sizeof table[0];
+
+int main() {
+  int a[10], m[3][4], i, *p;
+  char c;
+  struct point pt, *pp;
+
+  print(sizeof(int));
+  print(sizeof(char));
+  print(sizeof(int*));
+  print(sizeof(struct point));
+  putchar(' ');
+
+  print(sizeof a);
+  print(sizeof m);
+  print(sizeof m[0]);
+  print(sizeof a / sizeof a[0]);
+  putchar(' ');
+
+  print(sizeof c);
+  print(sizeof pt.tag);
+  print(sizeof *pp);
+  print(sizeof(c + 1));
+  putchar(' ');
+
+  i = 0;
+  print(sizeof(i++));
+  print(i);
+  print(sizeof(int) * 2);
+  print(size);
+}
@@ -0,0 +1,39 @@
+extern int total;
+static int calls;
+
+static int next_id() {
+  static int id = 100;
+  calls++;
+  return id++;
+}
+
+int counter() {
+  static int count;
+  count = count + 1;
+  return count;
+}
+
+void add(int n) {
+  extern int total;
+  total = total + n;
+}
+
+int total = 10;
+
+int main() {
+  int i;
+
+  print(next_id());
+  print(next_id());
+  print(next_id());
+  putchar(' ');
+
+  for (i = 0; i < 4; i++) counter();
+  print(counter());
+  print(calls);
+  putchar(' ');
+
+  add(5);
+  add(7);
+  print(total);
+}
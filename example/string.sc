@@ -0,0 +1,26 @@
+int strlen(char *s) {
+  int n;
+
+  n = 0;
+  while (*(s + n) != 0) {
+    n = n + 1;
+  }
+
+  return n;
+}
+
+void greet(char *name) {
+  print_string("hello, ");
+  print_string(name);
+  print_string("!");
+}
+
+int main() {
+  char *s;
+
+  s = "world";
+  greet(s);
+  greet("# not a comment");
+  print(strlen(s));
+  print(*(s + 1) == 'o');
+}
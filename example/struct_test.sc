@@ -0,0 +1,83 @@
+struct point {
+  char tag;
+  int x;
+  int y;
+};
+
+struct node {
+  int value;
+  struct node *next;
+};
+
+struct tree {
+  struct tree *left;
+  struct tree *right;
+};
+
+struct point origin;
+
+int sum(struct node *list, struct node *end) {
+  int total;
+  total = 0;
+
+  while (list != end) {
+    total = total + list->value;
+    list = list->next;
+  }
+
+  return total;
+}
+
+int max(int a, int b) {
+  if (a < b) {
+    return b;
+  }
+
+  return a;
+}
+
+int height(struct tree *t, struct tree *leaf) {
+  if (t == leaf) {
+    return 0;
+  }
+
+  return 1 + max(height(t->left, leaf), height(t->right, leaf));
+}
+
+int main() {
+  struct node sentinel;
+  struct node nodes[3];
+  struct point points[2];
+  struct point *p;
+  struct tree leaf;
+  struct tree trees[3];
+  int i;
+
+  for (i = 0; i < 3; i = i + 1) {
+    nodes[i].value = i + 1;
+    nodes[i].next = &sentinel;
+    if (i > 0) {
+      nodes[i - 1].next = &nodes[i];
+    }
+  }
+  print(sum(&nodes[0], &sentinel));
+
+  p = points + 1;
+  p->tag = 'p';
+  p->x = 3;
+  (*p).y = p->x * 10;
+  putchar(points[1].tag);
+  print(points[1].y + p->x);
+
+  origin.x = 7;
+  origin.y = origin.x - 4;
+  print(origin.x * 10 + origin.y);
+
+  trees[0].left = &trees[1];
+  trees[0].right = &leaf;
+  trees[1].left = &leaf;
+  trees[1].right = &trees[2];
+  trees[2].left = &leaf;
+  trees[2].right = &leaf;
+  print(height(trees, &leaf));
+}
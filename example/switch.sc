@@ -0,0 +1,42 @@
+int eval(int op, int a, int b) {
+  switch (op) {
+  case 0:
+    return a + b;
+  case 1:
+    return a - b;
+  case 2:
+    return a * b;
+  case 3:
+    return a / b;
+  case 5:
+    a = -a;
+  case 6:
+    return a;
+  default:
+    return 0;
+  }
+}
+
+int main() {
+  int i, n;
+
+  for (i = 0; i < 8; i = i + 1) {
+    print(eval(i, 7, 2));
+    putchar(' ');
+  }
+
+  n = 0;
+  for (i = 0; i < 10; i = i + 1) {
+    switch (i * 100) {
+    case 100:
+      n = n + 1;
+      break;
+    case 300:
+      continue;
+    case 900:
+      n = n + 10;
+    }
+    n = n + 100;
+  }
+  print(n);
+}
@@ -0,0 +1,31 @@
+int x;
+int y = 5;
+int z[2] = {7, 8};
+
+int f(int n) {
+  switch (n) {
+    case 0: return 10;
+    case 1: return 11;
+    case 2: return 12;
+    case 3: return 13;
+    case 4: return 14;
+  }
+
+  return -1;
+}
+
+int main() {
+  static int count = 3;
+
+  x = 12345;
+  print(f(0));
+  print(f(4));
+  print(f(5));
+  putchar(' ');
+  print(x);
+  print(y);
+  print(z[1]);
+  print(count);
+
+  return 0;
+}
@@ -0,0 +1,40 @@
+int calls;
+
+int max(int a, int b) {
+  return a > b ? a : b;
+}
+
+int count(int value) {
+  calls++;
+  return value;
+}
+
+int sign(int x) {
+  return x < 0 ? -1 : x > 0 ? 1 : 0;
+}
+
+int main() {
+  int a[2], *p;
+  char c;
+
+  print(max(3, 7));
+  print(max(9, 2));
+  putchar(' ');
+
+  print(1 ? count(4) : count(5));
+  print(calls);
+  putchar(' ');
+
+  print(sign(-3));
+  print(sign(0));
+  print(sign(8));
+  putchar(' ');
+
+  a[0] = 10;
+  a[1] = 20;
+  p = calls ? a + 1 : a;
+  print(*p);
+
+  c = 'x';
+  putchar(c == 'x' ? 'y' : 'n');
+}
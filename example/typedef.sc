@@ -0,0 +1,66 @@
+typedef int size_t;
+typedef char byte, *string;
+typedef int vector[3];
+typedef int (*binary)(int, int);
+
+typedef struct point {
+  int x;
+  int y;
+} point_t;
+typedef point_t *point_ptr;
+
+int add(int a, int b) { return a + b; }
+int mul(int a, int b) { return a * b; }
+
+size_t length(string s) {
+  size_t n;
+  n = 0;
+  while (s[n]) n++;
+  return n;
+}
+
+int fold(vector v, binary f, int init) {
+  int i;
+  for (i = 0; i < 3; i++) init = f(init, v[i]);
+  return init;
+}
+
+void move(point_ptr p, int dx) {
+  p->x = p->x + dx;
+  p->y = p->y - dx;
+}
+
+int main() {
+  vector v = {2, 3, 4};
+  point_t p;
+  byte c;
+  binary ops[2];
+
+  ops[0] = add;
+  ops[1] = mul;
+  print(fold(v, ops[0], 0));
+  print(fold(v, ops[1], 1));
+  putchar(' ');
+
+  print(length("hello"));
+  print(sizeof(vector));
+  print(sizeof(point_t));
+  putchar(' ');
+
+  p.x = 1;
+  p.y = 5;
+  move(&p, 2);
+  print(p.x);
+  print(p.y);
+  putchar(' ');
+
+  {
+    typedef char small_t;
+    small_t small;
+    small = 300;
+    print(small);
+    print(sizeof(small_t));
+  }
+  c = 'A';
+  putchar(c);
+}
@@ -0,0 +1,41 @@
+unsigned int hash(char *s) {
+  unsigned int h;
+  h = 5381;
+  while (*s) {
+    h = h * 33 + *s;
+    s++;
+  }
+  return h;
+}
+
+int main() {
+  unsigned int big, half, shift;
+  int min, i;
+
+  big = 0 - 1;
+  half = big / 2;
+  print(half);
+  print(big % 10);
+  print(big > 1);
+  print(-1 < 1);
+  putchar(' ');
+
+  shift = 28;
+  print(big >> shift);
+  print(-16 >> 2);
+  putchar(' ');
+
+  min = -2147483647 - 1;
+  print(min <= 0);
+  print(min > 0);
+  print(0 >= min);
+  i = 2147483647;
+  print(i <= i);
+  putchar(' ');
+
+  print(hash("small c compiler") % 100000);
+  putchar(' ');
+
+  print((unsigned int)(0 - 7) / 2 % 100000);
+  print(2147483647 + 1 < 0);
+}
@@ -0,0 +1,52 @@
+char heap[64];
+int used;
+
+void *alloc(int size) {
+  void *p;
+  p = heap + used;
+  used += (size + 3) / 4 * 4;
+  return p;
+}
+
+void *copy(void *dst, void *src, int n) {
+  char *d, *s;
+  d = dst;
+  s = src;
+  while (n--) *d++ = *s++;
+  return dst;
+}
+
+void swap(void *a, void *b, int n) {
+  char *x, *y, tmp;
+  x = a;
+  y = b;
+  while (n--) {
+    tmp = *x;
+    *x++ = *y;
+    *y++ = tmp;
+  }
+}
+
+int main() {
+  int a[3] = {1, 2, 3};
+  int *p, *q, i;
+  char *s;
+
+  p = alloc(3 * 4);
+  copy(p, a, 3 * 4);
+  for (i = 0; i < 3; i++) print(p[i]);
+  putchar(' ');
+
+  q = alloc(4);
+  *q = 9;
+  swap(p, q, 4);
+  print(*p);
+  print(*q);
+  print(q - p);
+  putchar(' ');
+
+  s = copy(alloc(3), "ok", 3);
+  putchar(s[0]);
+  putchar(s[1]);
+  print(used);
+}
@@ -11,6 +11,7 @@ import (
 type IRProgram struct {
 	Declarations []*IRVariableDeclaration
 	Functions    []*IRFunctionDefinition
+	Strings      []*IRStringDeclaration
 }
 
 type traverseAction (func(statement IRStatement) IRStatement)
@@ -55,6 +56,10 @@ func (s *IRProgram) String() string {
 		stmtStrs = append(stmtStrs, statement.String())
 	}
 
+	for _, str := range s.Strings {
+		declStrs = append(declStrs, str.String())
+	}
+
 	return strings.Join(declStrs, "\n") + "\n\n" + strings.Join(stmtStrs, "\n\n")
 }
 
@@ -67,12 +72,41 @@ type IRExpression interface {
 
 type IRVariableDeclaration struct {
 	Var *Symbol
+
+	// Init is the initial values of a global variable
+	// which are IRNumberExpression or IRStringExpression
+	Init []IRExpression
+}
+
+// IsReadOnly reports whether the variable is a const global with initial values,
+// which are placed in the read-only data section
+func (s *IRVariableDeclaration) IsReadOnly() bool {
+	return len(s.Init) > 0 && isConst(s.Var.Type)
 }
 
 func (s *IRVariableDeclaration) String() string {
+	if len(s.Init) > 0 {
+		var values []string
+		for _, value := range s.Init {
+			values = append(values, value.String())
+		}
+
+		return fmt.Sprintf("%v %v = {%s}", s.Var.Type, s.Var.Name, strings.Join(values, ", "))
+	}
+
 	return fmt.Sprintf("%v %v", s.Var.Type, s.Var.Name)
 }
 
+// IRStringDeclaration is a string literal placed in the data section
+type IRStringDeclaration struct {
+	Label string
+	Value string
+}
+
+func (s *IRStringDeclaration) String() string {
+	return fmt.Sprintf("%s: %q", s.Label, s.Value)
+}
+
 type IRFunctionDefinition struct {
 	Var        *Symbol
 	Parameters []*IRVariableDeclaration
@@ -98,18 +132,24 @@ func (s *IRAssignmentStatement) String() string {
 	return fmt.Sprintf("%v = %v", s.Var.Name, s.Expression)
 }
 
+// IRWriteStatement stores Src to the address Dest
+// Size is the byte width of the store (4 if zero)
 type IRWriteStatement struct {
 	Dest *Symbol
 	Src  *Symbol
+	Size int
 }
 
 func (s *IRWriteStatement) String() string {
 	return fmt.Sprintf("*%v = %v", s.Dest.Name, s.Src.Name)
 }
 
+// IRReadStatement loads the value at the address Src to Dest
+// Size is the byte width of the load (4 if zero)
 type IRReadStatement struct {
 	Dest *Symbol
 	Src  *Symbol
+	Size int
 }
 
 func (s *IRReadStatement) String() string {
@@ -161,6 +201,33 @@ func (s *IRCallStatement) String() string {
 	return fmt.Sprintf("%s = %s(%s)", s.Dest.Name, s.Func.Name, strings.Join(args, ", "))
 }
 
+// IRIndirectCallStatement calls the function at the address in Target
+type IRIndirectCallStatement struct {
+	Dest   *Symbol
+	Target *Symbol
+	Vars   []*Symbol
+}
+
+func (s *IRIndirectCallStatement) String() string {
+	var args []string
+	for _, symbol := range s.Vars {
+		args = append(args, symbol.Name)
+	}
+
+	return fmt.Sprintf("%s = (*%s)(%s)", s.Dest.Name, s.Target.Name, strings.Join(args, ", "))
+}
+
+// IRJumpTableStatement jumps to Labels[Var], or Default if Var is out of range
+type IRJumpTableStatement struct {
+	Var     *Symbol
+	Labels  []string
+	Default string
+}
+
+func (s *IRJumpTableStatement) String() string {
+	return fmt.Sprintf("switch %s [%s] else %s", s.Var.Name, strings.Join(s.Labels, ", "), s.Default)
+}
+
 type IRReturnStatement struct {
 	Var *Symbol
 }
@@ -231,6 +298,15 @@ func (e *IRBinaryExpression) String() string {
 	return fmt.Sprintf("(%s %v %v)", e.Operator, e.Left, e.Right)
 }
 
+// IRStringExpression is the address of a string literal
+type IRStringExpression struct {
+	Label string
+}
+
+func (e *IRStringExpression) String() string {
+	return e.Label
+}
+
 type IRAddressExpression struct {
 	Var *Symbol
 }
@@ -241,6 +317,38 @@ func (e *IRAddressExpression) String() string {
 
 var counter = map[string]int{}
 
+// jump targets of `break` and `continue` in the enclosing loops, innermost last
+var breakLabels, continueLabels []string
+
+// labels of case statements, assigned when their switch is compiled
+var caseLabels = map[*CaseStatement]string{}
+
+// a switch is compiled into a jump table if it has enough cases
+// and the table is not too sparse
+const (
+	jumpTableMinCases = 4
+	jumpTableMaxRatio = 3
+)
+
+// string literals of the program being compiled, interned by value
+var stringDeclarations []*IRStringDeclaration
+
+// static local variables, which are placed in the data section with the globals
+var staticDeclarations []*IRVariableDeclaration
+
+func internString(value string) *IRStringDeclaration {
+	for _, declaration := range stringDeclarations {
+		if declaration.Value == value {
+			return declaration
+		}
+	}
+
+	declaration := &IRStringDeclaration{Label: label("string"), Value: value}
+	stringDeclarations = append(stringDeclarations, declaration)
+
+	return declaration
+}
+
 func label(name string) string {
 	labelName := fmt.Sprintf("%s_%d", name, counter[name])
 	counter[name]++
@@ -261,12 +369,14 @@ func CompileIR(statements []Statement) *IRProgram {
 	var decls []*IRVariableDeclaration
 	var funcs []*IRFunctionDefinition
 
+	stringDeclarations = nil
+	staticDeclarations = nil
+
 	var irStatements []IRStatement
 	for _, statement := range statements {
 		switch s := statement.(type) {
 		case *Declaration:
-			symbols := findSymbolsFromDeclaration(s)
-			decls = append(decls, IRVariableDeclarations(symbols)...)
+			decls = append(decls, compileIRGlobalDeclaration(s)...)
 		default:
 			irStatements = append(irStatements, compileIRStatement(s))
 		}
@@ -282,8 +392,9 @@ func CompileIR(statements []Statement) *IRProgram {
 	}
 
 	return &IRProgram{
-		Declarations: decls,
+		Declarations: append(decls, staticDeclarations...),
 		Functions:    funcs,
+		Strings:      stringDeclarations,
 	}
 }
 
@@ -317,14 +428,17 @@ func compileIRStatement(statement Statement) IRStatement {
 
 	case *CompoundStatement:
 		var symbols []*Symbol
+		var statements []IRStatement
 		for _, d := range s.Declarations {
 			declaration, ok := d.(*Declaration)
-			if ok {
+			if ok && declaration.Storage == "static" {
+				staticDeclarations = append(staticDeclarations, compileIRGlobalDeclaration(declaration)...)
+			} else if ok && declaration.Storage != "extern" {
 				symbols = append(symbols, findSymbolsFromDeclaration(declaration)...)
+				statements = append(statements, compileIRInitializers(declaration)...)
 			}
 		}
 
-		var statements []IRStatement
 		for _, statement := range s.Statements {
 			statements = append(statements, compileIRStatement(statement))
 		}
@@ -351,9 +465,9 @@ func compileIRStatement(statement Statement) IRStatement {
 			}
 
 		case *FunctionCallExpression:
-			name := findIdentifierExpression(e.Identifier).Name
+			identifier, isIdentifier := e.Identifier.(*IdentifierExpression)
 
-			if isSystemCall(name) {
+			if isIdentifier && identifier.Symbol.IsFunction() && isSystemCall(identifier.Name) {
 				tmp := tmpvar()
 				arg, decls, beforeArg := compileIRExpression(e.Argument)
 
@@ -365,7 +479,7 @@ func compileIRStatement(statement Statement) IRStatement {
 							Expression: arg,
 						},
 						&IRSystemCallStatement{
-							Name: name,
+							Name: identifier.Name,
 							Var:  tmp,
 						},
 					),
@@ -387,18 +501,25 @@ func compileIRStatement(statement Statement) IRStatement {
 		falseLabel := label("false")
 		endLabel := label("end")
 
-		condition, decls, beforeCondition := compileIRExpression(s.Condition)
+		test, negated := splitNegation(s.Condition)
+		condition, decls, beforeCondition := compileIRExpression(test)
+
+		branch := &IRIfStatement{
+			Var:        conditionVar,
+			TrueLabel:  trueLabel,
+			FalseLabel: falseLabel,
+		}
+
+		if negated {
+			branch.TrueLabel, branch.FalseLabel = falseLabel, trueLabel
+		}
 
 		statements := []IRStatement{
 			&IRAssignmentStatement{
 				Var:        conditionVar,
 				Expression: condition,
 			},
-			&IRIfStatement{
-				Var:        conditionVar,
-				TrueLabel:  trueLabel,
-				FalseLabel: falseLabel,
-			},
+			branch,
 			&IRLabelStatement{Name: trueLabel},
 			compileIRStatement(s.TrueStatement),
 			&IRGotoStatement{Label: endLabel},
@@ -422,20 +543,91 @@ func compileIRStatement(statement Statement) IRStatement {
 		beginLabel := label("while_begin")
 		endLabel := label("while_end")
 
-		condition, decls, beforeCondition := compileIRExpression(s.Condition)
+		continueLabel := beginLabel
+		if s.Loop != nil {
+			continueLabel = label("while_continue")
+		}
+
+		test, negated := splitNegation(s.Condition)
+		condition, decls, beforeCondition := compileIRExpression(test)
 		statements := append([]IRStatement{&IRLabelStatement{Name: beginLabel}}, beforeCondition...)
 
+		breakLabels = append(breakLabels, endLabel)
+		continueLabels = append(continueLabels, continueLabel)
+		body := compileIRStatement(s.Statement)
+		breakLabels = breakLabels[:len(breakLabels)-1]
+		continueLabels = continueLabels[:len(continueLabels)-1]
+
+		exit := &IRIfStatement{Var: conditionVar, FalseLabel: endLabel}
+		if negated {
+			exit = &IRIfStatement{Var: conditionVar, TrueLabel: endLabel}
+		}
+
 		statements = append(statements,
 			&IRAssignmentStatement{
 				Var:        conditionVar,
 				Expression: condition,
 			},
-			&IRIfStatement{
+			exit,
+			body,
+		)
+
+		if s.Loop != nil {
+			statements = append(statements,
+				&IRLabelStatement{Name: continueLabel},
+				compileIRStatement(s.Loop),
+			)
+		}
+
+		statements = append(statements,
+			&IRGotoStatement{Label: beginLabel},
+			&IRLabelStatement{Name: endLabel},
+		)
+
+		return &IRCompoundStatement{
+			Declarations: append(IRVariableDeclarations([]*Symbol{conditionVar}), decls...),
+			Statements:   statements,
+		}
+
+	case *DoWhileStatement:
+		// begin:
+		//   s
+		// continue:
+		//   if (cond) goto begin
+		// end:
+		conditionVar := tmpvar()
+
+		beginLabel := label("do_begin")
+		continueLabel := label("do_continue")
+		endLabel := label("do_end")
+
+		breakLabels = append(breakLabels, endLabel)
+		continueLabels = append(continueLabels, continueLabel)
+		body := compileIRStatement(s.Statement)
+		breakLabels = breakLabels[:len(breakLabels)-1]
+		continueLabels = continueLabels[:len(continueLabels)-1]
+
+		test, negated := splitNegation(s.Condition)
+		condition, decls, beforeCondition := compileIRExpression(test)
+
+		loop := &IRIfStatement{Var: conditionVar, TrueLabel: beginLabel}
+		if negated {
+			loop = &IRIfStatement{Var: conditionVar, FalseLabel: beginLabel}
+		}
+
+		statements := []IRStatement{
+			&IRLabelStatement{Name: beginLabel},
+			body,
+			&IRLabelStatement{Name: continueLabel},
+		}
+
+		statements = append(statements, beforeCondition...)
+		statements = append(statements,
+			&IRAssignmentStatement{
 				Var:        conditionVar,
-				FalseLabel: endLabel,
+				Expression: condition,
 			},
-			compileIRStatement(s.Statement),
-			&IRGotoStatement{Label: beginLabel},
+			loop,
 			&IRLabelStatement{Name: endLabel},
 		)
 
@@ -444,6 +636,23 @@ func compileIRStatement(statement Statement) IRStatement {
 			Statements:   statements,
 		}
 
+	case *SwitchStatement:
+		return compileIRSwitchStatement(s)
+
+	case *CaseStatement:
+		return &IRCompoundStatement{
+			Statements: []IRStatement{
+				&IRLabelStatement{Name: caseLabels[s]},
+				compileIRStatement(s.Statement),
+			},
+		}
+
+	case *BreakStatement:
+		return &IRGotoStatement{Label: breakLabels[len(breakLabels)-1]}
+
+	case *ContinueStatement:
+		return &IRGotoStatement{Label: continueLabels[len(continueLabels)-1]}
+
 	case *ReturnStatement:
 		// return exp;
 		//
@@ -455,6 +664,10 @@ func compileIRStatement(statement Statement) IRStatement {
 		}
 
 		tmp := tmpvar()
+		if s.FunctionSymbol != nil {
+			// return value is converted to the return type (e.g. int -> char)
+			tmp.Type = s.FunctionSymbol.Type.(FunctionType).Return
+		}
 
 		value, decls, beforeValue := compileIRExpression(s.Value)
 		return &IRCompoundStatement{
@@ -465,11 +678,258 @@ func compileIRStatement(statement Statement) IRStatement {
 			),
 		}
 
+	case *StructDeclaration, *EnumDeclaration, *TypedefDeclaration:
+		// struct types and enumerators have no code
+		return nil
+
 	default:
 		panic("unexpected statement")
 	}
 }
 
+// splitNegation removes `!` and `== 0` from a condition
+// so that the branch tests the operand itself instead of a computed boolean
+func splitNegation(condition Expression) (Expression, bool) {
+	switch e := condition.(type) {
+	case *UnaryExpression:
+		if e.Operator == "!" {
+			value, negated := splitNegation(e.Value)
+			return value, !negated
+		}
+
+	case *BinaryExpression:
+		if isZero(e.Right) && (e.Operator == "==" || e.Operator == "!=") {
+			value, negated := splitNegation(e.Left)
+			return value, negated != (e.Operator == "==")
+		}
+	}
+
+	return condition, false
+}
+
+func isZero(expression Expression) bool {
+	number, ok := expression.(*NumberExpression)
+	return ok && number.Value == "0"
+}
+
+func compileIRSwitchStatement(s *SwitchStatement) IRStatement {
+	valueVar := tmpvar()
+	endLabel := label("switch_end")
+
+	value, decls, statements := compileIRExpression(s.Value)
+	decls = append(decls, &IRVariableDeclaration{Var: valueVar})
+	statements = append(statements, &IRAssignmentStatement{
+		Var:        valueVar,
+		Expression: value,
+	})
+
+	defaultLabel := endLabel
+	var values []int
+	labels := map[int]string{}
+	for _, c := range s.Cases {
+		if c.Value == nil {
+			caseLabels[c] = label("default")
+			defaultLabel = caseLabels[c]
+			continue
+		}
+
+		caseLabels[c] = label("case")
+		_, value := evaluateConstant(c.Value)
+		values = append(values, value)
+		labels[value] = caseLabels[c]
+	}
+
+	var dispatchDecls []*IRVariableDeclaration
+	var dispatch []IRStatement
+	if isDense(values) {
+		dispatchDecls, dispatch = compileIRJumpTable(valueVar, values, labels, defaultLabel)
+	} else {
+		// if (value == case0) goto case0
+		// if (value == case1) goto case1
+		// ...
+		// goto default
+		for _, v := range values {
+			conditionVar := tmpvar()
+			dispatchDecls = append(dispatchDecls, &IRVariableDeclaration{Var: conditionVar})
+			dispatch = append(dispatch,
+				&IRAssignmentStatement{
+					Var: conditionVar,
+					Expression: &IRBinaryExpression{
+						Operator: "==",
+						Left:     &IRVariableExpression{Var: valueVar},
+						Right:    &IRNumberExpression{Value: v},
+					},
+				},
+				&IRIfStatement{Var: conditionVar, TrueLabel: labels[v]},
+			)
+		}
+
+		dispatch = append(dispatch, &IRGotoStatement{Label: defaultLabel})
+	}
+
+	breakLabels = append(breakLabels, endLabel)
+	body := compileIRStatement(s.Statement)
+	breakLabels = breakLabels[:len(breakLabels)-1]
+
+	statements = append(statements, dispatch...)
+	statements = append(statements, body, &IRLabelStatement{Name: endLabel})
+
+	return &IRCompoundStatement{
+		Declarations: append(decls, dispatchDecls...),
+		Statements:   statements,
+	}
+}
+
+func isDense(values []int) bool {
+	if len(values) < jumpTableMinCases {
+		return false
+	}
+
+	min, max := minMax(values)
+
+	return max-min+1 <= jumpTableMaxRatio*len(values)
+}
+
+func minMax(values []int) (int, int) {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+
+		if v > max {
+			max = v
+		}
+	}
+
+	return min, max
+}
+
+// compileIRJumpTable jumps to labels[value] through a table indexed by value - min
+func compileIRJumpTable(valueVar *Symbol, values []int, labels map[int]string, defaultLabel string) ([]*IRVariableDeclaration, []IRStatement) {
+	min, max := minMax(values)
+
+	var table []string
+	for v := min; v <= max; v++ {
+		if l, found := labels[v]; found {
+			table = append(table, l)
+		} else {
+			table = append(table, defaultLabel)
+		}
+	}
+
+	index := tmpvar()
+
+	return IRVariableDeclarations([]*Symbol{index}), []IRStatement{
+		&IRAssignmentStatement{
+			Var: index,
+			Expression: &IRBinaryExpression{
+				Operator: "-",
+				Left:     &IRVariableExpression{Var: valueVar},
+				Right:    &IRNumberExpression{Value: min},
+			},
+		},
+		&IRJumpTableStatement{
+			Var:     index,
+			Labels:  table,
+			Default: defaultLabel,
+		},
+	}
+}
+
+// compileIRGlobalDeclaration evaluates the constant initializers of global variables
+// extern declarations have no storage, which is given by the definition
+func compileIRGlobalDeclaration(declaration *Declaration) []*IRVariableDeclaration {
+	var decls []*IRVariableDeclaration
+	if declaration.Storage == "extern" {
+		return decls
+	}
+
+	for _, declarator := range declaration.Declarators {
+		var init []IRExpression
+		for _, value := range initializerValues(declarator) {
+			if value == nil {
+				init = append(init, &IRNumberExpression{Value: 0})
+				continue
+			}
+
+			if str, isString := value.(*StringExpression); isString {
+				init = append(init, &IRStringExpression{Label: internString(str.Value).Label})
+				continue
+			}
+
+			_, number := evaluateConstant(value)
+			init = append(init, &IRNumberExpression{Value: number})
+		}
+
+		decls = append(decls, &IRVariableDeclaration{
+			Var:  findIdentifierExpression(declarator.Identifier).Symbol,
+			Init: init,
+		})
+	}
+
+	return decls
+}
+
+// compileIRInitializers assigns the initial values to local variables
+// arrays are filled with zero after the listed values
+func compileIRInitializers(declaration *Declaration) []IRStatement {
+	var statements []IRStatement
+	for _, declarator := range declaration.Declarators {
+		if declarator.Initializer == nil {
+			continue
+		}
+
+		identifier := findIdentifierExpression(declarator.Identifier)
+		values := initializerValues(declarator)
+
+		if _, isArray := identifier.Symbol.Type.(ArrayType); !isArray {
+			statements = append(statements, compileIRStatement(&ExpressionStatement{
+				Value: &BinaryExpression{
+					Left:     &IdentifierExpression{Name: identifier.Name, Symbol: identifier.Symbol},
+					Operator: "=",
+					Right:    values[0],
+				},
+			}))
+			continue
+		}
+
+		// address = &a + i * size
+		// tmp = value
+		// *address = tmp
+		size := scalarType(identifier.Symbol.Type).ByteSize()
+		for i, value := range values {
+			address := tmpvar()
+			tmp := tmpvar()
+
+			var irValue IRExpression = &IRNumberExpression{Value: 0}
+			var decls []*IRVariableDeclaration
+			var beforeValue []IRStatement
+			if value != nil {
+				irValue, decls, beforeValue = compileIRExpression(value)
+			}
+
+			statements = append(statements, &IRCompoundStatement{
+				Declarations: append(IRVariableDeclarations([]*Symbol{address, tmp}), decls...),
+				Statements: append(beforeValue,
+					&IRAssignmentStatement{
+						Var: address,
+						Expression: &IRBinaryExpression{
+							Operator: "+",
+							Left:     &IRAddressExpression{Var: identifier.Symbol},
+							Right:    &IRNumberExpression{Value: i * size},
+						},
+					},
+					&IRAssignmentStatement{Var: tmp, Expression: irValue},
+					&IRWriteStatement{Dest: address, Src: tmp, Size: size},
+				),
+			})
+		}
+	}
+
+	return statements
+}
+
 func IRVariableDeclarations(symbols []*Symbol) []*IRVariableDeclaration {
 	var declarations []*IRVariableDeclaration
 	for _, symbol := range symbols {
@@ -499,12 +959,34 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 			Value: value,
 		}, nil, nil
 
+	case *StringExpression:
+		return &IRStringExpression{
+			Label: internString(e.Value).Label,
+		}, nil, nil
+
 	case *IdentifierExpression:
+		// enumerators are replaced with their values
+		if e.Symbol.IsConstant() {
+			return &IRNumberExpression{Value: e.Symbol.Value}, nil, nil
+		}
+
+		// the value of a function name is its address
+		if e.Symbol.IsFunction() {
+			return &IRAddressExpression{
+				Var: e.Symbol,
+			}, nil, nil
+		}
+
 		return &IRVariableExpression{
 			Var: e.Symbol,
 		}, nil, nil
 
 	case *UnaryExpression:
+		// m[i] of int m[3][4] is the address of the row, and *f of a function pointer is f
+		if isArrayDereference(e) || isFunctionDereference(e) {
+			return compileIRExpression(e.Value)
+		}
+
 		if e.Operator == "*" {
 			result := tmpvar()
 			tmp := tmpvar()
@@ -515,7 +997,7 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 					Var:        tmp,
 					Expression: irValue,
 				},
-				&IRReadStatement{Dest: result, Src: tmp},
+				&IRReadStatement{Dest: result, Src: tmp, Size: byteSizeOfExpression(e)},
 			}
 
 			decls = append(IRVariableDeclarations([]*Symbol{result, tmp}), decls...)
@@ -527,14 +1009,56 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 		}
 
 		if e.Operator == "&" {
+			return compileIRAddress(e.Value)
+		}
+
+		if e.Operator == "~" {
+			// ~a  =>  a nor 0
 			value, decls, statements := compileIRExpression(e.Value)
-			v, _ := value.(*IRVariableExpression)
 
-			return &IRAddressExpression{
-				Var: v.Var,
+			return &IRBinaryExpression{
+				Operator: "nor",
+				Left:     value,
+				Right:    &IRNumberExpression{Value: 0},
 			}, decls, statements
 		}
 
+		if e.Operator == "!" {
+			// !a  =>  a ! 0
+			value, decls, statements := compileIRExpression(e.Value)
+
+			return &IRBinaryExpression{
+				Operator: "!",
+				Left:     value,
+				Right:    &IRNumberExpression{Value: 0},
+			}, decls, statements
+		}
+
+	case *MemberExpression:
+		address, decls, statements := compileIRAddress(e)
+
+		// array members are converted to their address
+		field, _ := findField(e)
+		if _, isArray := field.Type.(ArrayType); isArray {
+			return address, decls, statements
+		}
+
+		result := tmpvar()
+		tmp := tmpvar()
+
+		statements = append(statements,
+			&IRAssignmentStatement{
+				Var:        tmp,
+				Expression: address,
+			},
+			&IRReadStatement{Dest: result, Src: tmp, Size: field.Type.ByteSize()},
+		)
+		decls = append(IRVariableDeclarations([]*Symbol{result, tmp}), decls...)
+
+		return &IRVariableExpression{
+			Var: result,
+		}, decls, statements
+
 	case *BinaryExpression:
 		// return (a || b) && c
 		// v;
@@ -556,20 +1080,24 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 		//   v = 0;
 		// }
 
+		if e.IsCompoundAssignment() {
+			return compileIRUpdate(e, false)
+		}
+
 		if e.IsAssignment() {
 			// a = (b = c);
 			// *(p + 2) = 4
 			switch left := e.Left.(type) {
-			case *UnaryExpression:
-				// address = left
+			case *UnaryExpression, *MemberExpression:
+				// address = &left
 				// tmpRight = exp
 				// *address = tmpRight
-				if left.Operator == "*" {
+				if unary, ok := left.(*UnaryExpression); !ok || unary.Operator == "*" {
 					address := tmpvar()
 					tmpRight := tmpvar()
 
 					right, rightDecls, beforeRight := compileIRExpression(e.Right)
-					leftExpression, leftDecls, beforeLeft := compileIRExpression(left.Value)
+					leftExpression, leftDecls, beforeLeft := compileIRAddress(left)
 
 					decls := append(rightDecls, leftDecls...)
 					decls = append(IRVariableDeclarations([]*Symbol{address, tmpRight}), decls...)
@@ -583,7 +1111,7 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 							Var:        tmpRight,
 							Expression: right,
 						},
-						&IRWriteStatement{Dest: address, Src: tmpRight},
+						&IRWriteStatement{Dest: address, Src: tmpRight, Size: byteSizeOfExpression(left)},
 					}
 					statements = append(append(beforeLeft, beforeRight...), statements...)
 
@@ -650,37 +1178,69 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 		left, leftDecls, beforeLeft := compileIRExpression(e.Left)
 		right, rightDecls, beforeRight := compileIRExpression(e.Right)
 
-		t, _ := typeOfExpression(e)
-		switch t.(type) {
-		case PointerType:
-			leftType, _ := typeOfExpression(e.Left)
-
-			if _, isInt := leftType.(BasicType); isInt {
-				// 4 * r + l
-				left = &IRBinaryExpression{
-					Operator: "*",
-					Left:     &IRNumberExpression{Value: 4}, // int -> 4 bytes
-					Right:    left,
-				}
-			} else {
-				// l + 4 * r
-				right = &IRBinaryExpression{
-					Operator: "*",
-					Left:     &IRNumberExpression{Value: 4}, // int -> 4 bytes
-					Right:    right,
-				}
+		return compileIRArithmetic(e, left, right), append(leftDecls, rightDecls...), append(beforeLeft, beforeRight...)
+
+	case *ConditionalExpression:
+		// c ? a : b
+		// if (c) {
+		//   v = a;
+		// } else {
+		//   v = b;
+		// }
+		tmp := tmpvar()
+		tmp.Type, _ = typeOfExpression(e)
+
+		assign := func(value Expression) Statement {
+			return &ExpressionStatement{
+				Value: &BinaryExpression{
+					Operator: "=",
+					Left:     &IdentifierExpression{Symbol: tmp},
+					Right:    value,
+				},
 			}
 		}
 
-		return &IRBinaryExpression{
-			Operator: e.Operator,
-			Left:     left,
-			Right:    right,
-		}, append(leftDecls, rightDecls...), append(beforeLeft, beforeRight...)
+		decls := IRVariableDeclarations([]*Symbol{tmp})
+		statements := []IRStatement{
+			compileIRStatement(&IfStatement{
+				Condition:      e.Condition,
+				TrueStatement:  assign(e.TrueValue),
+				FalseStatement: assign(e.FalseValue),
+			}),
+		}
 
-	case *FunctionCallExpression:
-		funcIdentifier := findIdentifierExpression(e.Identifier)
+		return &IRVariableExpression{Var: tmp}, decls, statements
+
+	case *SizeofExpression:
+		// the operand is not evaluated
+		t, _ := typeOfSizeof(e)
+		return &IRNumberExpression{Value: t.ByteSize()}, nil, nil
+
+	case *CastExpression:
+		value, decls, statements := compileIRExpression(e.Value)
 
+		// (char) a  =>  tmp = a where tmp is char, which is truncated and sign-extended
+		if e.Type.ByteSize() == 1 && byteSizeOfExpression(e.Value) != 1 {
+			tmp := tmpvar()
+			tmp.Type = e.Type
+
+			decls = append(decls, &IRVariableDeclaration{Var: tmp})
+			statements = append(statements, &IRAssignmentStatement{Var: tmp, Expression: value})
+
+			return &IRVariableExpression{Var: tmp}, decls, statements
+		}
+
+		return value, decls, statements
+
+	case *PostfixExpression:
+		// a++  =>  old = a; a = old + 1; old
+		return compileIRUpdate(&BinaryExpression{
+			Left:     e.Value,
+			Operator: e.Operator[:1] + "=",
+			Right:    &NumberExpression{Value: "1"},
+		}, true)
+
+	case *FunctionCallExpression:
 		var args []Expression
 		switch arg := e.Argument.(type) {
 		case *ExpressionList:
@@ -712,12 +1272,27 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 
 		result := tmpvar()
 
-		// result = f(a0, a1, ...)
-		statements = append(statements, &IRCallStatement{
-			Dest: result,
-			Func: funcIdentifier.Symbol,
-			Vars: argSymbols,
-		})
+		if identifier, ok := e.Identifier.(*IdentifierExpression); ok && identifier.Symbol.IsFunction() {
+			// result = f(a0, a1, ...)
+			statements = append(statements, &IRCallStatement{
+				Dest: result,
+				Func: identifier.Symbol,
+				Vars: argSymbols,
+			})
+		} else {
+			// target = f
+			// result = (*target)(a0, a1, ...)
+			target := tmpvar()
+			callee, calleeDecls, beforeCallee := compileIRExpression(e.Identifier)
+
+			decls = append(decls, calleeDecls...)
+			decls = append(decls, &IRVariableDeclaration{Var: target})
+			statements = append(statements, beforeCallee...)
+			statements = append(statements,
+				&IRAssignmentStatement{Var: target, Expression: callee},
+				&IRIndirectCallStatement{Dest: result, Target: target, Vars: argSymbols},
+			)
+		}
 
 		decls = append(decls, IRVariableDeclarations(append(argSymbols, result))...)
 		return &IRVariableExpression{
@@ -729,6 +1304,156 @@ func compileIRExpression(expression Expression) (IRExpression, []*IRVariableDecl
 	panic(fmt.Sprintf("unexpected expression: `%v`", reflect.TypeOf(expression)))
 }
 
+// compileIRArithmetic returns `left op right` of e, scaling the integer operand of pointer arithmetic
+func compileIRArithmetic(e *BinaryExpression, left IRExpression, right IRExpression) IRExpression {
+	t, _ := typeOfExpression(e)
+	switch t := t.(type) {
+	case PointerType:
+		leftType, _ := typeOfExpression(e.Left)
+		size := t.Value.ByteSize() // int -> 4 bytes, char -> 1 byte
+
+		if _, isInt := leftType.(BasicType); isInt {
+			// size * r + l
+			left = &IRBinaryExpression{
+				Operator: "*",
+				Left:     &IRNumberExpression{Value: size},
+				Right:    left,
+			}
+		} else {
+			// l + size * r
+			right = &IRBinaryExpression{
+				Operator: "*",
+				Left:     &IRNumberExpression{Value: size},
+				Right:    right,
+			}
+		}
+
+	default:
+		// p - q  =>  (p - q) / size
+		leftType, _ := typeOfExpression(e.Left)
+		if pointerType, isPointer := leftType.(PointerType); isPointer && e.Operator == "-" {
+			return &IRBinaryExpression{
+				Operator: "/",
+				Left: &IRBinaryExpression{
+					Operator: "-",
+					Left:     left,
+					Right:    right,
+				},
+				Right: &IRNumberExpression{Value: pointerType.Value.ByteSize()},
+			}
+		}
+	}
+
+	return &IRBinaryExpression{
+		Operator: operatorOf(e),
+		Left:     left,
+		Right:    right,
+	}
+}
+
+// compileIRUpdate compiles the compound assignment `a op= b`
+// the address of `a` is evaluated only once, and the old value is returned if postfix is true
+func compileIRUpdate(e *BinaryExpression, postfix bool) (IRExpression, []*IRVariableDeclaration, []IRStatement) {
+	arithmetic := arithmeticOf(e)
+	leftType, _ := typeOfExpression(e.Left)
+
+	old := tmpvar()
+	old.Type = leftType
+	decls := IRVariableDeclarations([]*Symbol{old})
+
+	if identifier, ok := e.Left.(*IdentifierExpression); ok {
+		// old = a
+		// a = old op b
+		right, rightDecls, beforeRight := compileIRExpression(e.Right)
+		value := compileIRArithmetic(arithmetic, &IRVariableExpression{Var: old}, right)
+
+		statements := append(beforeRight,
+			&IRAssignmentStatement{Var: old, Expression: &IRVariableExpression{Var: identifier.Symbol}},
+			&IRAssignmentStatement{Var: identifier.Symbol, Expression: value},
+		)
+
+		if postfix {
+			return &IRVariableExpression{Var: old}, append(decls, rightDecls...), statements
+		}
+
+		return &IRVariableExpression{Var: identifier.Symbol}, append(decls, rightDecls...), statements
+	}
+
+	// address = &a
+	// old = *address
+	// result = old op b
+	// *address = result
+	address := tmpvar()
+	result := tmpvar()
+	result.Type = leftType
+	size := byteSizeOfExpression(e.Left)
+
+	leftAddress, leftDecls, beforeLeft := compileIRAddress(e.Left)
+	right, rightDecls, beforeRight := compileIRExpression(e.Right)
+
+	decls = append(decls, IRVariableDeclarations([]*Symbol{address, result})...)
+	decls = append(decls, append(leftDecls, rightDecls...)...)
+
+	statements := append(beforeLeft,
+		&IRAssignmentStatement{Var: address, Expression: leftAddress},
+		&IRReadStatement{Dest: old, Src: address, Size: size},
+	)
+	statements = append(statements, beforeRight...)
+	statements = append(statements,
+		&IRAssignmentStatement{Var: result, Expression: compileIRArithmetic(arithmetic, &IRVariableExpression{Var: old}, right)},
+		&IRWriteStatement{Dest: address, Src: result, Size: size},
+	)
+
+	if postfix {
+		return &IRVariableExpression{Var: old}, decls, statements
+	}
+
+	return &IRVariableExpression{Var: result}, decls, statements
+}
+
+// compileIRAddress returns the address of the object which expression refers to
+func compileIRAddress(expression Expression) (IRExpression, []*IRVariableDeclaration, []IRStatement) {
+	switch e := expression.(type) {
+	case *IdentifierExpression:
+		return &IRAddressExpression{
+			Var: e.Symbol,
+		}, nil, nil
+
+	case *UnaryExpression:
+		// &*p  =>  p
+		if e.Operator == "*" {
+			return compileIRExpression(e.Value)
+		}
+
+	case *MemberExpression:
+		// &s.m  =>  &s + offset of m
+		target, decls, statements := compileIRAddress(e.Target)
+		field, _ := findField(e)
+
+		if field.Offset == 0 {
+			return target, decls, statements
+		}
+
+		return &IRBinaryExpression{
+			Operator: "+",
+			Left:     target,
+			Right:    &IRNumberExpression{Value: field.Offset},
+		}, decls, statements
+	}
+
+	panic(fmt.Sprintf("unexpected expression: `%v`", reflect.TypeOf(expression)))
+}
+
+// byteSizeOfExpression returns the size of the value which expression refers to
+func byteSizeOfExpression(expression Expression) int {
+	t, err := typeOfExpression(expression)
+	if err != nil {
+		return Int().ByteSize()
+	}
+
+	return t.ByteSize()
+}
+
 func assignStatementBySymbol(symbol *Symbol, value int) *ExpressionStatement {
 	return &ExpressionStatement{
 		Value: &BinaryExpression{
@@ -741,7 +1466,7 @@ func assignStatementBySymbol(symbol *Symbol, value int) *ExpressionStatement {
 
 func isSystemCall(name string) bool {
 	switch name {
-	case "print", "putchar":
+	case "print", "putchar", "print_string":
 		return true
 	}
 
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -75,6 +77,79 @@ func TestCompileIR(t *testing.T) {
 	}
 }
 
+func TestCompileIRBreakAndContinue(t *testing.T) {
+	statements, _ := Parse(`
+      int main() {
+        int i;
+        for (i = 0; i < 10; i = i + 1) {
+          if (i == 2) continue;
+          break;
+        }
+      }
+    `)
+
+	for i, statement := range statements {
+		statements[i] = Walk(statement)
+	}
+	Analyze(statements, &Env{})
+
+	ir := CompileIR(statements)
+	code := ir.Functions[0].String()
+
+	for _, pattern := range []string{`goto while_continue_\d+`, `goto while_end_\d+`} {
+		if !regexp.MustCompile(pattern).MatchString(code) {
+			t.Errorf("expect `%v` in %v", pattern, code)
+		}
+	}
+}
+
+func TestCompileIRSwitch(t *testing.T) {
+	compile := func(cases string) string {
+		statements := ast(`
+      int main() {
+        int a;
+        switch (a) {` + cases + `}
+      }
+    `)
+
+		return CompileIR(statements).Functions[0].String()
+	}
+
+	dense := compile(`case 1: case 2: case 3: case 5: break;`)
+	if !regexp.MustCompile(`switch #tmp_\d+ \[case_\d+, case_\d+, case_\d+, switch_end_\d+, case_\d+\] else switch_end_\d+`).MatchString(dense) {
+		t.Errorf("expect a jump table, got %v", dense)
+	}
+
+	sparse := compile(`case 1: case 100: case 10000: case 5: default: break;`)
+	if strings.Contains(sparse, "switch #tmp") || !strings.Contains(sparse, "goto default_") {
+		t.Errorf("expect a compare chain, got %v", sparse)
+	}
+}
+
+func TestCompileIRLogicalNot(t *testing.T) {
+	statements := ast(`
+      int main() {
+        int a, b;
+        if (!a) b = 1;
+        while (a == 0) a = 1;
+        b = !a;
+      }
+    `)
+
+	code := CompileIR(statements).Functions[0].String()
+
+	// conditions branch on `a` itself with swapped labels
+	for _, pattern := range []string{
+		`#tmp_\d+ = a\n\s*if \(#tmp_\d+\) false_\d+ else true_\d+`,
+		`#tmp_\d+ = a\n\s*if \(#tmp_\d+\) while_end_\d+`,
+		`= \(! a 0\)`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(code) {
+			t.Errorf("expect `%v` in %v", pattern, code)
+		}
+	}
+}
+
 func TestCompileIRStatement(t *testing.T) {
 	// int a;
 	// int *p;
@@ -136,6 +211,85 @@ func TestCompileIRStatement(t *testing.T) {
 	}
 }
 
+func TestCompileIRCharPointer(t *testing.T) {
+	// char *p;
+	symbolP := &Symbol{Name: "p", Type: Pointer(Char())}
+
+	// *(p + 3)
+	e := &UnaryExpression{
+		Operator: "*",
+		Value: &BinaryExpression{
+			Operator: "+",
+			Left:     &IdentifierExpression{Symbol: symbolP},
+			Right:    &NumberExpression{Value: "3"},
+		},
+	}
+
+	_, _, statements := compileIRExpression(e)
+
+	assignment := statements[0].(*IRAssignmentStatement)
+	if assignment.Expression.String() != "(+ p (* 1 3))" {
+		t.Errorf("expect char* arithmetic to be scaled by 1, got %v", assignment.Expression)
+	}
+
+	read := statements[1].(*IRReadStatement)
+	if read.Size != 1 {
+		t.Errorf("expect a 1 byte read, got %v", read.Size)
+	}
+}
+
+func TestCompileIRString(t *testing.T) {
+	statements := ast(`
+    void print_string(char *s);
+
+    int main() {
+      char *s;
+      s = "hello";
+      print_string("hello");
+      print_string("world");
+    }
+  `)
+
+	ir := CompileIR(statements)
+	if len(ir.Strings) != 2 {
+		t.Errorf("expect 2 interned strings, got %v", ir.Strings)
+		return
+	}
+
+	if !(ir.Strings[0].Value == "hello" && ir.Strings[1].Value == "world") {
+		t.Errorf("expect `hello` and `world`, got %v", ir.Strings)
+	}
+}
+
+func TestCompileIRStatic(t *testing.T) {
+	statements := ast(`
+    extern int g;
+    int g;
+
+    int main() {
+      static int count = 3;
+      extern int g;
+      int a;
+      count++;
+    }
+  `)
+
+	ir := CompileIR(statements)
+	if len(ir.Declarations) != 2 {
+		t.Fatalf("expect g and count in the data section, got %v", ir.Declarations)
+	}
+
+	count := ir.Declarations[1]
+	if !(count.Var.Name == "count" && count.Var.IsGlobal() && len(count.Init) == 1 && count.Init[0].String() == "3") {
+		t.Errorf("expect static count = {3}, got %v", count)
+	}
+
+	body := ir.Functions[0].Body.(*IRCompoundStatement)
+	if len(body.Declarations) != 1 || body.Declarations[0].Var.Name != "a" {
+		t.Errorf("expect only `a` in the frame, got %v", body.Declarations)
+	}
+}
+
 func TestCompileIRExpression(t *testing.T) {
 	// 0 || 1
 	e := &BinaryExpression{
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"strconv"
 	"strings"
 	"text/scanner"
 )
@@ -13,23 +14,228 @@ type Lexer struct {
 	token      Token
 	pos        scanner.Position
 	errMessage string
+
+	// names of each block scope, true for typedef names which are lexed as TYPE
+	// and false for ordinary identifiers which hide the typedef names of outer scopes
+	typedefs []map[string]bool
+
+	// the kind of each open brace, STRUCT or ENUM for their bodies and '{' for blocks
+	braces []int
+
+	// STRUCT or ENUM if the next `{` opens its body
+	tag int
+
+	// parameters declared in the next function body
+	parameters []string
+
+	// a typedef name is an identifier where a declarator or a member name is expected,
+	// like after a type specifier or after `,` in declarators
+	expectName    bool
+	inDeclaration bool
+	parens        int
+	last          int
+
+	// original positions of the lines of preprocessed code
+	lines [][]Segment
+
+	// errors of invalid literals, which don't stop parsing
+	errs      []error
+	scanError string
 }
 
 func (l *Lexer) Init(code string) {
 	l.scanner.Init(strings.NewReader(code))
+	l.scanner.Error = func(s *scanner.Scanner, message string) {
+		l.scanError = message
+	}
+	l.typedefs = []map[string]bool{{}}
+}
+
+// AddTypedef makes name a type name until the end of the current block
+// it is called by the parser when a typedef declaration is reduced
+func (l *Lexer) AddTypedef(name string) {
+	l.typedefs[len(l.typedefs)-1][name] = true
+}
+
+// addTypedefs calls AddTypedef for the names of declarators
+// the names are lexed as TYPE from the next token
+func addTypedefs(l yyLexer, declarators []*Declarator) {
+	for _, declarator := range declarators {
+		l.(*Lexer).AddTypedef(findIdentifierExpression(declarator.Identifier).Name)
+	}
+}
+
+// AddIdentifier makes name an identifier until the end of the current block
+// it is called by the parser when a declaration or an enumerator is reduced
+// struct members have their own namespace, so they don't hide typedef names
+func (l *Lexer) AddIdentifier(name string) {
+	if l.innerBrace() != STRUCT {
+		l.typedefs[len(l.typedefs)-1][name] = false
+	}
+}
+
+// addIdentifiers calls AddIdentifier for the names of declarators
+func addIdentifiers(l yyLexer, declarators []*Declarator) {
+	for _, declarator := range declarators {
+		l.(*Lexer).AddIdentifier(findIdentifierExpression(declarator.Identifier).Name)
+	}
+}
+
+// AddParameter makes name an identifier in the function body which follows
+func (l *Lexer) AddParameter(name string) {
+	l.parameters = append(l.parameters, name)
+}
+
+func (l *Lexer) isTypedef(name string) bool {
+	for i := len(l.typedefs) - 1; i >= 0; i-- {
+		if isTypedef, ok := l.typedefs[i][name]; ok {
+			return isTypedef
+		}
+	}
+
+	return false
+}
+
+func (l *Lexer) innerBrace() int {
+	if len(l.braces) == 0 {
+		return 0
+	}
+
+	return l.braces[len(l.braces)-1]
+}
+
+// openBrace opens the scope of a block or a struct body, or the body of an enum which has no scope
+func (l *Lexer) openBrace() {
+	kind := int('{')
+	if l.tag != 0 {
+		kind = l.tag
+	}
+
+	l.braces = append(l.braces, kind)
+	if kind == ENUM {
+		return
+	}
+
+	scope := map[string]bool{}
+	if kind == '{' {
+		for _, name := range l.parameters {
+			scope[name] = false
+		}
+
+		l.parameters = nil
+	}
+
+	l.typedefs = append(l.typedefs, scope)
+}
+
+// closeBrace closes the innermost brace and returns its kind
+func (l *Lexer) closeBrace() int {
+	kind := l.innerBrace()
+	if kind == 0 {
+		return 0
+	}
+
+	if kind != ENUM {
+		l.typedefs = l.typedefs[:len(l.typedefs)-1]
+	}
+
+	l.braces = l.braces[:len(l.braces)-1]
+	return kind
+}
+
+// track updates the state which decides whether the next typedef name is an identifier
+func (l *Lexer) track(token int) {
+	expectName := false
+
+	switch token {
+	case TYPE, UNSIGNED, STRUCT, ENUM, '.', ARROW:
+		expectName = true
+
+	case IDENT:
+		// the tag of struct or enum completes a type specifier
+		expectName = l.last == STRUCT || l.last == ENUM
+
+	case '*', CONST:
+		expectName = l.expectName
+
+	case '(':
+		expectName = l.expectName
+		l.parens++
+
+	case ')':
+		l.parens--
+
+	case ',':
+		expectName = l.innerBrace() == ENUM || (l.inDeclaration && l.parens == 0)
+
+	case '{':
+		l.openBrace()
+		expectName = l.innerBrace() == ENUM
+		l.inDeclaration = false
+
+	case ';':
+		l.parameters = nil
+		l.inDeclaration = false
+
+	case '}':
+		// the declarators of `typedef struct s { ... } name;` follow the body
+		kind := l.closeBrace()
+		expectName = kind == STRUCT || kind == ENUM
+		l.inDeclaration = false
+	}
+
+	if expectName && l.parens == 0 && (token == TYPE || token == UNSIGNED || token == IDENT) {
+		l.inDeclaration = true
+	}
+
+	switch {
+	case token == STRUCT || token == ENUM:
+		l.tag = token
+
+	case token == IDENT && (l.last == STRUCT || l.last == ENUM):
+
+	default:
+		l.tag = 0
+	}
+
+	l.expectName = expectName
+	l.last = token
 }
 
 var keywords = map[string]int{
-	"int":    TYPE,
-	"void":   TYPE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"while":  WHILE,
-	"for":    FOR,
+	"int":      TYPE,
+	"char":     TYPE,
+	"void":     TYPE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"do":       DO,
+	"for":      FOR,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"switch":   SWITCH,
+	"case":     CASE,
+	"default":  DEFAULT,
+	"struct":   STRUCT,
+	"sizeof":   SIZEOF,
+	"enum":     ENUM,
+	"typedef":  TYPEDEF,
+	"static":   STATIC,
+	"extern":   EXTERN,
+	"const":    CONST,
+	"unsigned": UNSIGNED,
 }
 
 func (l *Lexer) Lex(lval *yySymType) int {
+	token := l.scan(lval)
+	l.track(token)
+
+	return token
+}
+
+func (l *Lexer) scan(lval *yySymType) int {
+	l.scanError = ""
 	tok := l.scanner.Scan()
 
 	if tok == scanner.EOF {
@@ -37,13 +243,39 @@ func (l *Lexer) Lex(lval *yySymType) int {
 	}
 
 	lit := l.scanner.TokenText()
-	pos := l.scanner.Pos()
+	pos := l.position(l.scanner.Pos())
 
 	lval.token = Token{lit: lit, pos: pos}
 	l.token = lval.token
 
-	if regexp.MustCompile(`^(0|[1-9][0-9]*)$`).MatchString(lit) {
+	// literals are checked with the rules of C instead of the errors of scanner
+	switch tok {
+	case scanner.Int, scanner.Float:
+		_, err := parseInteger(lit)
+		l.literalError(err)
 		return NUMBER
+
+	case scanner.Char:
+		if l.scanError == "literal not terminated" {
+			l.literalError(errors.New("missing terminating ' character"))
+		} else {
+			_, err := parseCharacter(lit)
+			l.literalError(err)
+		}
+		return CHAR
+
+	case scanner.String:
+		if l.scanError == "literal not terminated" {
+			l.literalError(errors.New("missing terminating \" character"))
+		} else {
+			_, err := unescape(literalBody(lit))
+			l.literalError(err)
+		}
+		return STRING
+	}
+
+	if l.scanError != "" {
+		l.literalError(errors.New(l.scanError))
 	}
 
 	if keywords[lit] != 0 {
@@ -58,29 +290,206 @@ func (l *Lexer) Lex(lval *yySymType) int {
 		">=": GEQ,
 		"&&": LOGICAL_AND,
 		"||": LOGICAL_OR,
+		"->": ARROW,
+		"<<": LSHIFT,
+		">>": RSHIFT,
+		"++": INC,
+		"--": DEC,
+		"+=": ASSIGN_OP,
+		"-=": ASSIGN_OP,
+		"*=": ASSIGN_OP,
+		"/=": ASSIGN_OP,
+		"%=": ASSIGN_OP,
+		"&=": ASSIGN_OP,
+		"|=": ASSIGN_OP,
+		"^=": ASSIGN_OP,
 	}
 
 	if operators[two] != 0 {
 		l.scanner.Next()
+
+		// <<= and >>=
+		if (two == "<<" || two == ">>") && l.scanner.Peek() == '=' {
+			l.scanner.Next()
+			lval.token = Token{lit: two + "=", pos: pos}
+			l.token = lval.token
+			return ASSIGN_OP
+		}
+
 		lval.token = Token{lit: two, pos: pos}
 		l.token = lval.token
 		return operators[two]
 	}
 
-	if regexp.MustCompile(`^'.*'$`).MatchString(lit) {
-		return CHAR
-	}
-
 	switch lit {
-	case "(", ")", "{", "}", "&", ";", ",", "[", "]", "+", "-", "*", "/", "<", ">", "=":
+	case "(", ")", "{", "}", "&", ";", ",", "[", "]", "+", "-", "*", "/", "<", ">", "=", ":", ".", "%", "|", "^", "~", "!", "?":
 		return int(tok)
 
 	default:
+		if l.isTypedef(lit) && !l.expectName {
+			return TYPE
+		}
+
 		return IDENT
 	}
 }
 
+// literalError records err at the start of the current token
+func (l *Lexer) literalError(err error) {
+	if err != nil {
+		l.errs = append(l.errs, SemanticError{Pos: l.position(l.scanner.Position), Err: err})
+	}
+}
+
+// position maps pos in preprocessed code to the file, line and column it comes from
+func (l *Lexer) position(pos scanner.Position) scanner.Position {
+	if pos.Line < 1 || pos.Line > len(l.lines) {
+		return pos
+	}
+
+	segments := l.lines[pos.Line-1]
+	segment := segments[0]
+	for _, s := range segments[1:] {
+		if s.Column > pos.Column {
+			break
+		}
+
+		segment = s
+	}
+
+	origin := segment.Pos
+	if !segment.Fixed {
+		origin.Column += pos.Column - segment.Column
+	}
+
+	pos.Filename = origin.Filename
+	pos.Line = origin.Line
+	pos.Column = origin.Column
+
+	return pos
+}
+
 func (l *Lexer) Error(e string) {
 	l.pos = l.token.pos
 	l.errMessage = e
 }
+
+// parseInteger returns the value of a decimal, octal (0), hex (0x) or binary (0b) integer constant
+// the value must fit in 32 bits, and the constants over the range of int are unsigned
+func parseInteger(lit string) (int, error) {
+	base, digits := 10, lit
+	lower := strings.ToLower(lit)
+
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		base, digits = 16, lit[2:]
+	case strings.HasPrefix(lower, "0b"):
+		base, digits = 2, lit[2:]
+	case len(lit) > 1 && lit[0] == '0':
+		base, digits = 8, lit[1:]
+	}
+
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return 0, fmt.Errorf("invalid integer constant `%s`", lit)
+	}
+
+	if err != nil || value > 0xffffffff {
+		return 0, fmt.Errorf("integer constant `%s` is too large", lit)
+	}
+
+	return int(value), nil
+}
+
+// parseCharacter returns the value of a char literal, which is a signed char
+func parseCharacter(lit string) (int, error) {
+	value, err := unescape(literalBody(lit))
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(value) {
+	case 0:
+		return 0, errors.New("empty character constant")
+	case 1:
+		return int(int8(value[0])), nil
+	}
+
+	return 0, fmt.Errorf("multi-character character constant %s", lit)
+}
+
+// literalBody returns the content between the quotes of a char or string literal
+func literalBody(lit string) string {
+	if len(lit) < 2 {
+		return ""
+	}
+
+	return lit[1 : len(lit)-1]
+}
+
+var escapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '\'': '\'', '"': '"', '?': '?',
+}
+
+// unescape replaces the escape sequences in the body of a char or string literal
+func unescape(body string) (string, error) {
+	var result []byte
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			result = append(result, body[i])
+			continue
+		}
+
+		i++
+		if i == len(body) {
+			return "", errors.New("missing escape sequence after `\\`")
+		}
+
+		if ch, ok := escapes[body[i]]; ok {
+			result = append(result, ch)
+			continue
+		}
+
+		switch {
+		case '0' <= body[i] && body[i] <= '7':
+			// \ooo has up to 3 octal digits
+			end := i + 1
+			for end < len(body) && end < i+3 && '0' <= body[end] && body[end] <= '7' {
+				end++
+			}
+
+			value, _ := strconv.ParseUint(body[i:end], 8, 32)
+			if value > 0xff {
+				return "", fmt.Errorf("octal escape sequence `\\%s` out of range", body[i:end])
+			}
+
+			result = append(result, byte(value))
+			i = end - 1
+
+		case body[i] == 'x':
+			end := i + 1
+			for end < len(body) && strings.IndexByte("0123456789abcdefABCDEF", body[end]) >= 0 {
+				end++
+			}
+
+			if end == i+1 {
+				return "", errors.New("`\\x` used with no following hex digits")
+			}
+
+			value, err := strconv.ParseUint(body[i+1:end], 16, 32)
+			if err != nil || value > 0xff {
+				return "", fmt.Errorf("hex escape sequence `\\%s` out of range", body[i:end])
+			}
+
+			result = append(result, byte(value))
+			i = end - 1
+
+		default:
+			return "", fmt.Errorf("unknown escape sequence `\\%c`", body[i])
+		}
+	}
+
+	return string(result), nil
+}
@@ -7,13 +7,31 @@ import (
 func TestLex(t *testing.T) {
 	testLex(t, `42 7 0`, []int{NUMBER, NUMBER, NUMBER})
 	testLex(t, `a == 100`, []int{IDENT, EQL, NUMBER})
+	testLex(t, `char c`, []int{TYPE, IDENT})
+	testLex(t, `i++ + --j`, []int{IDENT, INC, '+', DEC, IDENT})
+	testLex(t, `a <<= 2 >> b`, []int{IDENT, ASSIGN_OP, NUMBER, RSHIFT, IDENT})
+}
+
+func TestLexTypedefName(t *testing.T) {
+	l := new(Lexer)
+	l.Init(`number n`)
+	l.AddTypedef("number")
+
+	var sym yySymType
+	if token := l.Lex(&sym); token != TYPE {
+		t.Errorf("expect TYPE, got %v", token)
+	}
+
+	if token := l.Lex(&sym); token != IDENT {
+		t.Errorf("expect IDENT, got %v", token)
+	}
 }
 
 func testLex(t *testing.T, code string, tokens []int) {
 	l := new(Lexer)
-	l.Init(`a == 100`)
+	l.Init(code)
 
-	tokenTypes := []int{IDENT, EQL, NUMBER}
+	tokenTypes := tokens
 	result := []int{}
 
 	var sym yySymType
@@ -35,3 +53,91 @@ func testLex(t *testing.T, code string, tokens []int) {
 		}
 	}
 }
+
+func TestParseInteger(t *testing.T) {
+	cases := []struct {
+		Literal string
+		Value   int
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"0x2A", 42},
+		{"0XfF", 255},
+		{"052", 42},
+		{"0b101010", 42},
+		{"2147483648", 0x80000000},
+		{"0xffffffff", 0xffffffff},
+	}
+
+	for _, c := range cases {
+		value, err := parseInteger(c.Literal)
+		if err != nil || value != c.Value {
+			t.Errorf("expect %v to be %v, got %v (%v)", c.Literal, c.Value, value, err)
+		}
+	}
+
+	for _, lit := range []string{"09", "0x", "0b12", "0o17", "1_000", "1.5", "0x100000000", "4294967296"} {
+		if _, err := parseInteger(lit); err == nil {
+			t.Errorf("expect error for %v, got nil", lit)
+		}
+	}
+}
+
+func TestParseCharacter(t *testing.T) {
+	cases := []struct {
+		Literal string
+		Value   int
+	}{
+		{`'a'`, 'a'},
+		{`'\n'`, '\n'},
+		{`'\0'`, 0},
+		{`'\''`, '\''},
+		{`'"'`, '"'},
+		{`'\\'`, '\\'},
+		{`'\?'`, '?'},
+		{`'\a'`, 7},
+		{`'\101'`, 'A'},
+		{`'\x41'`, 'A'},
+		{`'\xff'`, -1},
+		{`'\377'`, -1},
+	}
+
+	for _, c := range cases {
+		value, err := parseCharacter(c.Literal)
+		if err != nil || value != c.Value {
+			t.Errorf("expect %v to be %v, got %v (%v)", c.Literal, c.Value, value, err)
+		}
+	}
+
+	for _, lit := range []string{`''`, `'ab'`, `'\q'`, `'\400'`, `'\x'`, `'\x100'`, `'\'`} {
+		if _, err := parseCharacter(lit); err == nil {
+			t.Errorf("expect error for %v, got nil", lit)
+		}
+	}
+
+	value, err := unescape(`a\tb\"\\\0010`)
+	if err != nil || value != "a\tb\"\\\x010" {
+		t.Errorf("expect escape sequences to be replaced, got %q (%v)", value, err)
+	}
+}
+
+func TestLexLiteralError(t *testing.T) {
+	cases := []struct {
+		Source   string
+		Position string
+	}{
+		{"int a = 0x100000000;", "1:9"},
+		{"int a = 09;", "1:9"},
+		{"int a;\nchar c = '\\q';", "2:10"},
+		{"char *s = \"\\x\";", "1:11"},
+		{"char *s = \"abc;", "1:11"},
+	}
+
+	for _, c := range cases {
+		_, err := Parse(c.Source)
+		e, ok := err.(SemanticError)
+		if !(ok && formatPosition(e.Pos) == c.Position) {
+			t.Errorf("%q: expect error at %v, got %v", c.Source, c.Position, err)
+		}
+	}
+}
@@ -6,18 +6,42 @@ import (
 
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/k0kubun/pp"
+	"github.com/uiureo/small-c/mips"
 )
 
+// includePaths is a list of -I directories
+type includePaths []string
+
+func (paths *includePaths) String() string {
+	return strings.Join(*paths, ",")
+}
+
+func (paths *includePaths) Set(path string) error {
+	*paths = append(*paths, path)
+	return nil
+}
+
 func main() {
 	optimize := flag.Bool("optimize", true, "Enable optimization")
+	var includes includePaths
+	flag.Var(&includes, "I", "Add a directory to search for #include files")
 	flag.Parse()
 
+	// small-c run file.sc
+	args := flag.Args()
+	run := len(args) > 0 && args[0] == "run"
+	if run {
+		args = args[1:]
+	}
+
 	var src string
+	var filename string
 
-	if len(os.Args) > 1 {
-		filename := os.Args[len(os.Args)-1]
+	if len(args) > 0 {
+		filename = args[len(args)-1]
 		data, err := ioutil.ReadFile(filename)
 		if err != nil {
 			fmt.Println(err)
@@ -29,17 +53,38 @@ func main() {
 		src = string(data)
 	}
 
-	code, errs := CompileSource(src, *optimize)
+	code, errs := CompileFile(filename, src, includes, *optimize)
 	if len(errs) > 0 {
 		Exit(src, errs)
 	}
+
+	if run {
+		err := mips.Run(code, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	fmt.Println(code)
 }
 
 func CompileSource(src string, optimize bool) (string, []error) {
+	return CompileFile("", src, nil, optimize)
+}
+
+// CompileFile compiles src read from filename, and #include searches includePaths
+func CompileFile(filename string, src string, includePaths []string, optimize bool) (string, []error) {
 	debug := len(os.Getenv("DEBUG")) > 0
 
-	statements, err := Parse(src)
+	source, err := Preprocess(filename, src, includePaths)
+	if err != nil {
+		return "", []error{err}
+	}
+
+	statements, err := ParseSource(source)
 	if err != nil {
 		return "", []error{err}
 	}
@@ -55,6 +100,7 @@ func CompileSource(src string, optimize bool) (string, []error) {
 	prelude, _ := Parse(`
 		void print(int i);
 		void putchar(int ch);
+		void print_string(const char *s);
 	`)
 	statements = append(prelude, statements...)
 
@@ -88,7 +134,7 @@ func Exit(src string, errs []error) {
 	for _, err := range errs {
 		switch e := err.(type) {
 		case SemanticError:
-			err = fmt.Errorf("%d:%d: %v", e.Pos.Line, e.Pos.Column, e.Err)
+			err = fmt.Errorf("%s: %v", formatPosition(e.Pos), e.Err)
 
 		default:
 		}
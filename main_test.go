@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/uiureo/small-c/mips"
 )
 
 func TestSimulateExample(t *testing.T) {
@@ -16,16 +20,41 @@ func TestSimulateExample(t *testing.T) {
 	}){
 		{"example/sum.sc", "1"},
 		{"example/sum_for.sc", "45"},
+		{"example/break_continue.sc", "1210"},
+		{"example/do_while.sc", "108"},
+		{"example/switch.sc", "9 5 14 3 0 -7 7 0 911"},
+		{"example/switch_global.sc", "1014-1 12345583"},
 		{"example/many_args.sc", "6"},
 		{"example/factorial.sc", "24"},
 		{"example/fib.sc", "89"},
 		{"example/global_var.sc", "11"},
 		{"example/if_test.sc", ""},
 		{"example/pointer_test.sc", "1"},
+		{"example/char_test.sc", "hellolo44-128"},
+		{"example/struct_test.sc", "6p33733"},
+		{"example/bitwise.sc", "2-2 8146 -1316-8 24012 322"},
+		{"example/logical_not.sc", "20101130zy"},
+		{"example/increment.sc", "6 151 152015 19 1275 127-128-127"},
+		{"example/ternary.sc", "79 41 -101 20y"},
+		{"example/initializer.sc", "3170 hello world! -86714 02040"},
+		{"example/matrix.sc", "-4 -4 12 -15 -18 9 -8 -15 -5 34 zaz"},
+		{"example/pointer.sc", "5 21 41 1411 1"},
+		{"example/void_pointer.sc", "123 913 ok20"},
+		{"example/cast.sc", "44 -56127 -1-2 104 A"},
+		{"example/sizeof.sc", "41412 40481610 11124 40812"},
+		{"example/function_pointer.sc", "10421 1024 611 12 21"},
+		{"example/enum.sc", "507 11032 71"},
+		{"example/typedef.sc", "924 5128 33 441A"},
+		{"example/static_extern.sc", "100101102 53 22"},
+		{"example/const.sc", "60365 ff4c 7 done"},
+		{"example/unsigned.sc", "2147483647511 15-4 1011 79676 836441"},
+		{"example/preprocess.sc", "30 69 15 56 SIZE"},
+		{"example/literal.sc", "42 268435455 01 30-1'ABC?"},
 		{"example/optimize_constant.sc", "1"},
 		{"example/bubble_sort.sc", "12345678"},
-		{"example/quick_sort.sc", "12345678"},
+		{"example/quick_sort.sc", "12345678 87654321"},
 		{"example/putchar.sc", "hello world"},
+		{"example/string.sc", "hello, world!hello, # not a comment!51"},
 		{"example/gcd.sc", "21"},
 		{"example/prime.sc", "2 3 5 7 11 13 17 19 23 29 "},
 		{"example/emoji.sc", "45"},
@@ -59,6 +88,37 @@ func TestSimulateExample(t *testing.T) {
 	}
 }
 
+func TestExternLabel(t *testing.T) {
+	code, errs := CompileSource("extern int x; int main() { print(x); x = 1; print(x); }", true)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// x is defined by another unit
+	if _, err := mips.Assemble(code); err == nil || !strings.Contains(err.Error(), "undefined label `var.x`") {
+		t.Errorf("expect x to be undefined, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := mips.Run(code+".data\nvar.x: .word 42\n", &out); err != nil || out.String() != "421" {
+		t.Errorf("expect 421, got %q (%v)", out.String(), err)
+	}
+
+	// globals named like the labels of the compiler
+	code, errs = CompileSource(`
+    int main_exit, string_0 = 1, jump_table_0 = 2;
+    int main() { main_exit = 3; print(main_exit); print("x" != 0); print(string_0 + jump_table_0); return 0; }
+  `, true)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	out.Reset()
+	if err := mips.Run(code, &out); err != nil || out.String() != "313" {
+		t.Errorf("expect 313, got %q (%v)", out.String(), err)
+	}
+}
+
 func TestSampleOk(t *testing.T) {
 	sampleFiles, _ := filepath.Glob("sample/ok*.sc")
 	for _, sampleFile := range sampleFiles {
@@ -106,7 +166,7 @@ func compileAndSave(filename string) error {
 		return err
 	}
 
-	code, errs := CompileSource(string(src), true)
+	code, errs := CompileFile(filename, string(src), nil, true)
 	for _, err := range errs {
 		return err
 	}
@@ -120,16 +180,35 @@ func compileAndSave(filename string) error {
 	return nil
 }
 
+// runSpim runs the assembly with spim, or with the built-in simulator when spim is not installed
 func runSpim(filename string) (string, error) {
-	byteOut, err := exec.Command("spim", "-file", filename).Output()
-	if err != nil {
-		return "", err
+	var output string
+
+	if _, err := exec.LookPath("spim"); err == nil {
+		byteOut, err := exec.Command("spim", "-file", filename).Output()
+		if err != nil {
+			return "", err
+		}
+
+		output = string(byteOut)
+	} else {
+		code, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+
+		var out bytes.Buffer
+		err = mips.Run(string(code), &out)
+		if err != nil {
+			return "", fmt.Errorf("%v: %v", filename, err)
+		}
+
+		output = out.String()
 	}
 
-	lines := strings.Split(string(byteOut), "\n")
-	output := lines[len(lines)-1]
+	lines := strings.Split(output, "\n")
 
-	return output, nil
+	return lines[len(lines)-1], nil
 }
 
 func testOk(t *testing.T, sourceFilename string) {
@@ -0,0 +1,420 @@
+package mips
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	textBase = 0x00400000
+	dataBase = 0x10000000
+)
+
+type Instruction struct {
+	Op     string
+	Rd     int
+	Rs     int
+	Rt     int
+	Imm    int32
+	Target string
+	Line   int
+}
+
+// Program is an assembled MIPS program
+type Program struct {
+	Text   []*Instruction
+	Data   []byte
+	Labels map[string]uint32
+
+	// ReadOnly is the address ranges [start, end) of the data placed by .rdata
+	ReadOnly [][2]uint32
+}
+
+var registers = map[string]int{
+	"zero": 0, "at": 1, "v0": 2, "v1": 3,
+	"a0": 4, "a1": 5, "a2": 6, "a3": 7,
+	"t0": 8, "t1": 9, "t2": 10, "t3": 11, "t4": 12, "t5": 13, "t6": 14, "t7": 15,
+	"s0": 16, "s1": 17, "s2": 18, "s3": 19, "s4": 20, "s5": 21, "s6": 22, "s7": 23,
+	"t8": 24, "t9": 25, "k0": 26, "k1": 27,
+	"gp": 28, "sp": 29, "fp": 30, "ra": 31,
+}
+
+var labelPattern = regexp.MustCompile(`^[A-Za-z_.$][A-Za-z0-9_.$]*$`)
+
+// operand formats of each instruction
+// d, s, t: registers, i: immediate, m: imm(register) or label, l: label
+var formats = map[string]string{
+	"add":     "dst",
+	"sub":     "dst",
+	"mul":     "dst",
+	"div":     "dst",
+	"divu":    "dst",
+	"rem":     "dst",
+	"remu":    "dst",
+	"slt":     "dst",
+	"sltu":    "dst",
+	"and":     "dst",
+	"or":      "dst",
+	"xor":     "dst",
+	"nor":     "dst",
+	"sllv":    "dts",
+	"srav":    "dts",
+	"srlv":    "dts",
+	"addi":    "tsi",
+	"slti":    "tsi",
+	"sltiu":   "tsi",
+	"sll":     "dti",
+	"li":      "ti",
+	"la":      "tl",
+	"lw":      "tm",
+	"sw":      "tm",
+	"lb":      "tm",
+	"sb":      "tm",
+	"beq":     "stl",
+	"j":       "l",
+	"jal":     "l",
+	"jr":      "s",
+	"jalr":    "s",
+	"syscall": "",
+}
+
+// Assemble parses MIPS assembly and resolves labels
+func Assemble(src string) (*Program, error) {
+	program := &Program{Labels: map[string]uint32{}}
+	inText := true
+
+	// .word operands which refer to labels
+	type fixup struct {
+		offset int
+		label  string
+		line   int
+	}
+	var fixups []fixup
+
+	// the start of the current .rdata section, 0 if not in it
+	readOnlyStart := uint32(0)
+	endReadOnly := func() {
+		if readOnlyStart != 0 {
+			program.ReadOnly = append(program.ReadOnly, [2]uint32{readOnlyStart, dataBase + uint32(len(program.Data))})
+			readOnlyStart = 0
+		}
+	}
+
+	for i, line := range strings.Split(src, "\n") {
+		lineNumber := i + 1
+
+		line = strings.TrimSpace(stripComment(line))
+
+		// label: ...
+		var names []string
+		for {
+			index := strings.Index(line, ":")
+			if index < 0 || !labelPattern.MatchString(line[:index]) {
+				break
+			}
+
+			names = append(names, line[:index])
+			line = strings.TrimSpace(line[index+1:])
+		}
+
+		op, rest := splitOperator(line)
+
+		if !inText && op == ".word" {
+			// words (and their labels) are aligned
+			for len(program.Data)%4 != 0 {
+				program.Data = append(program.Data, 0)
+			}
+		}
+
+		for _, name := range names {
+			if _, found := program.Labels[name]; found {
+				return nil, fmt.Errorf("%d: label `%s` is already defined", lineNumber, name)
+			}
+
+			if inText {
+				program.Labels[name] = textBase + uint32(4*len(program.Text))
+			} else {
+				program.Labels[name] = dataBase + uint32(len(program.Data))
+			}
+		}
+
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(op, ".") {
+			if inText && (op == ".asciiz" || op == ".word" || op == ".byte") {
+				return nil, fmt.Errorf("%d: `%s` in text section", lineNumber, op)
+			}
+
+			switch op {
+			case ".data", ".rdata":
+				inText = false
+				endReadOnly()
+
+				// .data address
+				if len(rest) > 0 {
+					address, err := parseImmediate(rest)
+					if err != nil {
+						return nil, fmt.Errorf("%d: %v", lineNumber, err)
+					}
+
+					end := dataBase + uint32(len(program.Data))
+					if uint32(address) < end {
+						return nil, fmt.Errorf("%d: data address 0x%08x overlaps data before 0x%08x", lineNumber, uint32(address), end)
+					}
+
+					program.Data = append(program.Data, make([]byte, uint32(address)-end)...)
+				}
+
+				if op == ".rdata" {
+					readOnlyStart = dataBase + uint32(len(program.Data))
+				}
+			case ".text":
+				inText = true
+				endReadOnly()
+			case ".globl":
+			case ".asciiz":
+				str, err := parseString(rest)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %v", lineNumber, err)
+				}
+
+				program.Data = append(program.Data, str...)
+				program.Data = append(program.Data, 0)
+			case ".byte":
+				for _, operand := range strings.Split(rest, ",") {
+					value, err := parseImmediate(strings.TrimSpace(operand))
+					if err != nil {
+						return nil, fmt.Errorf("%d: %v", lineNumber, err)
+					}
+
+					program.Data = append(program.Data, byte(value))
+				}
+			case ".word":
+				for _, operand := range strings.Split(rest, ",") {
+					operand = strings.TrimSpace(operand)
+
+					value, err := parseImmediate(operand)
+					if err != nil {
+						if !labelPattern.MatchString(operand) {
+							return nil, fmt.Errorf("%d: %v", lineNumber, err)
+						}
+
+						fixups = append(fixups, fixup{offset: len(program.Data), label: operand, line: lineNumber})
+					}
+
+					word := make([]byte, 4)
+					binary.LittleEndian.PutUint32(word, uint32(value))
+					program.Data = append(program.Data, word...)
+				}
+			default:
+				return nil, fmt.Errorf("%d: unknown directive `%s`", lineNumber, op)
+			}
+
+			continue
+		}
+
+		if !inText {
+			return nil, fmt.Errorf("%d: instruction `%s` in data section", lineNumber, op)
+		}
+
+		instruction, err := parseInstruction(op, rest)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %v", lineNumber, err)
+		}
+
+		instruction.Line = lineNumber
+		program.Text = append(program.Text, instruction)
+	}
+
+	endReadOnly()
+
+	for _, instruction := range program.Text {
+		if len(instruction.Target) > 0 {
+			address, found := program.Labels[instruction.Target]
+			if !found {
+				return nil, fmt.Errorf("%d: undefined label `%s`", instruction.Line, instruction.Target)
+			}
+
+			// lw $t0, label
+			if strings.Contains(formats[instruction.Op], "m") {
+				instruction.Imm = int32(address)
+			}
+		}
+	}
+
+	for _, f := range fixups {
+		address, found := program.Labels[f.label]
+		if !found {
+			return nil, fmt.Errorf("%d: undefined label `%s`", f.line, f.label)
+		}
+
+		binary.LittleEndian.PutUint32(program.Data[f.offset:], address)
+	}
+
+	return program, nil
+}
+
+// stripComment removes `# ...` outside of string literals
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+
+	return line
+}
+
+// parseString decodes a quoted string with escape sequences
+func parseString(operand string) ([]byte, error) {
+	if len(operand) < 2 || operand[0] != '"' || operand[len(operand)-1] != '"' {
+		return nil, fmt.Errorf("expect string, got `%s`", operand)
+	}
+
+	var str []byte
+	body := operand[1 : len(operand)-1]
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		if ch != '\\' {
+			str = append(str, ch)
+			continue
+		}
+
+		i++
+		if i >= len(body) {
+			return nil, fmt.Errorf("invalid escape sequence in %s", operand)
+		}
+
+		switch body[i] {
+		case 'n':
+			str = append(str, '\n')
+		case 't':
+			str = append(str, '\t')
+		case 'r':
+			str = append(str, '\r')
+		case '0':
+			str = append(str, 0)
+		case '\\', '"', '\'':
+			str = append(str, body[i])
+		default:
+			return nil, fmt.Errorf("invalid escape sequence `\\%c` in %s", body[i], operand)
+		}
+	}
+
+	return str, nil
+}
+
+func splitOperator(line string) (string, string) {
+	index := strings.IndexAny(line, " \t")
+	if index < 0 {
+		return line, ""
+	}
+
+	return line[:index], strings.TrimSpace(line[index+1:])
+}
+
+func parseInstruction(op string, rest string) (*Instruction, error) {
+	format, found := formats[op]
+	if !found {
+		return nil, fmt.Errorf("unknown instruction `%s`", op)
+	}
+
+	var operands []string
+	if len(rest) > 0 {
+		for _, operand := range strings.Split(rest, ",") {
+			operands = append(operands, strings.TrimSpace(operand))
+		}
+	}
+
+	if len(operands) != len(format) {
+		return nil, fmt.Errorf("`%s` takes %d operands, got %d", op, len(format), len(operands))
+	}
+
+	instruction := &Instruction{Op: op}
+	for i, kind := range format {
+		operand := operands[i]
+
+		var err error
+		switch kind {
+		case 'd':
+			instruction.Rd, err = parseRegister(operand)
+		case 's':
+			instruction.Rs, err = parseRegister(operand)
+		case 't':
+			instruction.Rt, err = parseRegister(operand)
+		case 'i':
+			instruction.Imm, err = parseImmediate(operand)
+		case 'm':
+			// label, which is addressed from $zero
+			if labelPattern.MatchString(operand) {
+				instruction.Target = operand
+				break
+			}
+
+			// offset($register)
+			open := strings.Index(operand, "(")
+			if open < 0 || !strings.HasSuffix(operand, ")") {
+				return nil, fmt.Errorf("invalid memory operand `%s`", operand)
+			}
+
+			offset := strings.TrimSpace(operand[:open])
+			if len(offset) > 0 {
+				instruction.Imm, err = parseImmediate(offset)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			instruction.Rs, err = parseRegister(operand[open+1 : len(operand)-1])
+		case 'l':
+			instruction.Target = operand
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instruction, nil
+}
+
+func parseRegister(operand string) (int, error) {
+	operand = strings.TrimSpace(operand)
+	if !strings.HasPrefix(operand, "$") {
+		return 0, fmt.Errorf("expect register, got `%s`", operand)
+	}
+
+	name := operand[1:]
+	if number, found := registers[name]; found {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(name)
+	if err != nil || number < 0 || number > 31 {
+		return 0, fmt.Errorf("unknown register `%s`", operand)
+	}
+
+	return number, nil
+}
+
+func parseImmediate(operand string) (int32, error) {
+	value, err := strconv.ParseInt(operand, 0, 64)
+	if err != nil || value < -1<<31 || value > 1<<32-1 {
+		return 0, fmt.Errorf("invalid immediate `%s`", operand)
+	}
+
+	return int32(value), nil
+}
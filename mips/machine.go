@@ -0,0 +1,333 @@
+package mips
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	globalPointer = 0x10008000
+	stackPointer  = 0x7fffeffc
+
+	// main returns to this address
+	exitAddress = 0
+
+	pageSize = 4096
+
+	DefaultStepLimit = 100000000
+)
+
+const (
+	zero = 0
+	v0   = 2
+	a0   = 4
+	gp   = 28
+	sp   = 29
+	fp   = 30
+	ra   = 31
+)
+
+// Machine executes an assembled program like spim does
+type Machine struct {
+	Registers [32]int32
+	PC        uint32
+	Output    io.Writer
+	StepLimit int
+
+	program *Program
+	memory  map[uint32][]byte
+	halted  bool
+}
+
+func NewMachine(program *Program, output io.Writer) *Machine {
+	m := &Machine{
+		Output:    output,
+		StepLimit: DefaultStepLimit,
+		program:   program,
+		memory:    map[uint32][]byte{},
+	}
+
+	for i, b := range program.Data {
+		m.storeByte(dataBase+uint32(i), b)
+	}
+
+	m.Registers[gp] = globalPointer
+	m.Registers[sp] = stackPointer
+	m.Registers[fp] = stackPointer
+	m.Registers[ra] = exitAddress
+
+	return m
+}
+
+// Run assembles src and executes it from `main`
+func Run(src string, output io.Writer) error {
+	program, err := Assemble(src)
+	if err != nil {
+		return err
+	}
+
+	return NewMachine(program, output).Run()
+}
+
+func (m *Machine) Run() error {
+	entry, found := m.program.Labels["main"]
+	if !found {
+		return errors.New("label `main` is not defined")
+	}
+
+	m.PC = entry
+	for steps := 0; !m.halted && m.PC != exitAddress; steps++ {
+		if m.StepLimit > 0 && steps >= m.StepLimit {
+			return fmt.Errorf("step limit %d exceeded", m.StepLimit)
+		}
+
+		if err := m.Step(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Machine) Step() error {
+	index := int(m.PC-textBase) / 4
+	if m.PC < textBase || m.PC%4 != 0 || index >= len(m.program.Text) {
+		return fmt.Errorf("invalid program counter 0x%08x", m.PC)
+	}
+
+	instruction := m.program.Text[index]
+	m.PC += 4
+
+	if err := m.execute(instruction); err != nil {
+		return fmt.Errorf("%d: %s: %v", instruction.Line, instruction.Op, err)
+	}
+
+	m.Registers[zero] = 0
+
+	return nil
+}
+
+func (m *Machine) execute(inst *Instruction) error {
+	r := &m.Registers
+
+	switch inst.Op {
+	case "add":
+		r[inst.Rd] = r[inst.Rs] + r[inst.Rt]
+
+	case "sub":
+		r[inst.Rd] = r[inst.Rs] - r[inst.Rt]
+
+	case "mul":
+		r[inst.Rd] = r[inst.Rs] * r[inst.Rt]
+
+	case "div":
+		if r[inst.Rt] == 0 {
+			return errors.New("division by zero")
+		}
+		r[inst.Rd] = r[inst.Rs] / r[inst.Rt]
+
+	case "rem":
+		if r[inst.Rt] == 0 {
+			return errors.New("division by zero")
+		}
+		r[inst.Rd] = r[inst.Rs] % r[inst.Rt]
+
+	case "divu":
+		if r[inst.Rt] == 0 {
+			return errors.New("division by zero")
+		}
+		r[inst.Rd] = int32(uint32(r[inst.Rs]) / uint32(r[inst.Rt]))
+
+	case "remu":
+		if r[inst.Rt] == 0 {
+			return errors.New("division by zero")
+		}
+		r[inst.Rd] = int32(uint32(r[inst.Rs]) % uint32(r[inst.Rt]))
+
+	case "and":
+		r[inst.Rd] = r[inst.Rs] & r[inst.Rt]
+
+	case "or":
+		r[inst.Rd] = r[inst.Rs] | r[inst.Rt]
+
+	case "xor":
+		r[inst.Rd] = r[inst.Rs] ^ r[inst.Rt]
+
+	case "nor":
+		r[inst.Rd] = ^(r[inst.Rs] | r[inst.Rt])
+
+	case "sllv":
+		r[inst.Rd] = r[inst.Rt] << uint(r[inst.Rs]&31)
+
+	case "srav":
+		r[inst.Rd] = r[inst.Rt] >> uint(r[inst.Rs]&31)
+
+	case "srlv":
+		r[inst.Rd] = int32(uint32(r[inst.Rt]) >> uint(r[inst.Rs]&31))
+
+	case "slt":
+		r[inst.Rd] = boolToInt(r[inst.Rs] < r[inst.Rt])
+
+	case "sltu":
+		r[inst.Rd] = boolToInt(uint32(r[inst.Rs]) < uint32(r[inst.Rt]))
+
+	case "addi":
+		r[inst.Rt] = r[inst.Rs] + inst.Imm
+
+	case "slti":
+		r[inst.Rt] = boolToInt(r[inst.Rs] < inst.Imm)
+
+	case "sltiu":
+		r[inst.Rt] = boolToInt(uint32(r[inst.Rs]) < uint32(inst.Imm))
+
+	case "sll":
+		r[inst.Rd] = r[inst.Rt] << uint(inst.Imm&31)
+
+	case "li":
+		r[inst.Rt] = inst.Imm
+
+	case "la":
+		r[inst.Rt] = int32(m.program.Labels[inst.Target])
+
+	case "lw":
+		value, err := m.loadWord(uint32(r[inst.Rs] + inst.Imm))
+		if err != nil {
+			return err
+		}
+		r[inst.Rt] = value
+
+	case "sw":
+		return m.storeWord(uint32(r[inst.Rs]+inst.Imm), r[inst.Rt])
+
+	case "lb":
+		r[inst.Rt] = int32(int8(m.loadByte(uint32(r[inst.Rs] + inst.Imm))))
+
+	case "sb":
+		address := uint32(r[inst.Rs] + inst.Imm)
+		if err := m.checkWritable(address, 1); err != nil {
+			return err
+		}
+		m.storeByte(address, byte(r[inst.Rt]))
+
+	case "beq":
+		if r[inst.Rs] == r[inst.Rt] {
+			m.PC = m.program.Labels[inst.Target]
+		}
+
+	case "j":
+		m.PC = m.program.Labels[inst.Target]
+
+	case "jal":
+		r[ra] = int32(m.PC)
+		m.PC = m.program.Labels[inst.Target]
+
+	case "jr":
+		m.PC = uint32(r[inst.Rs])
+
+	case "jalr":
+		target := uint32(r[inst.Rs])
+		r[ra] = int32(m.PC)
+		m.PC = target
+
+	case "syscall":
+		return m.syscall()
+
+	default:
+		return errors.New("unimplemented instruction")
+	}
+
+	return nil
+}
+
+func (m *Machine) syscall() error {
+	switch m.Registers[v0] {
+	case 1:
+		// print_int
+		fmt.Fprint(m.Output, m.Registers[a0])
+
+	case 4:
+		// print_string
+		for address := uint32(m.Registers[a0]); ; address++ {
+			ch := m.loadByte(address)
+			if ch == 0 {
+				break
+			}
+
+			m.Output.Write([]byte{ch})
+		}
+
+	case 10:
+		// exit
+		m.halted = true
+
+	case 11:
+		// print_char
+		m.Output.Write([]byte{byte(m.Registers[a0])})
+
+	default:
+		return fmt.Errorf("unknown system call %d", m.Registers[v0])
+	}
+
+	return nil
+}
+
+func (m *Machine) page(address uint32) []byte {
+	base := address &^ (pageSize - 1)
+	page := m.memory[base]
+	if page == nil {
+		page = make([]byte, pageSize)
+		m.memory[base] = page
+	}
+
+	return page[address-base:]
+}
+
+func (m *Machine) loadByte(address uint32) byte {
+	return m.page(address)[0]
+}
+
+func (m *Machine) storeByte(address uint32, value byte) {
+	m.page(address)[0] = value
+}
+
+func (m *Machine) loadWord(address uint32) (int32, error) {
+	if address%4 != 0 {
+		return 0, fmt.Errorf("unaligned address 0x%08x", address)
+	}
+
+	return int32(binary.LittleEndian.Uint32(m.page(address))), nil
+}
+
+func (m *Machine) storeWord(address uint32, value int32) error {
+	if address%4 != 0 {
+		return fmt.Errorf("unaligned address 0x%08x", address)
+	}
+
+	if err := m.checkWritable(address, 4); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(m.page(address), uint32(value))
+	return nil
+}
+
+// checkWritable returns an error if the size bytes at address overlap the data placed by .rdata
+func (m *Machine) checkWritable(address uint32, size uint32) error {
+	for _, r := range m.program.ReadOnly {
+		if address < r[1] && r[0] < address+size {
+			return fmt.Errorf("write to read-only address 0x%08x", address)
+		}
+	}
+
+	return nil
+}
+
+func boolToInt(b bool) int32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,317 @@
+package mips
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAssemble(t *testing.T) {
+	program, err := Assemble(`
+.data
+.text
+.globl main
+
+main:
+addi $sp, $sp, -8
+sw $ra, 4($sp)
+lw $t0, -4($fp) # comment
+beq $t0, $zero, main_exit
+main_exit:
+jr $ra
+`)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(program.Text) != 5 {
+		t.Errorf("expect 5 instructions, got %v", len(program.Text))
+	}
+
+	if program.Labels["main_exit"] != textBase+4*4 {
+		t.Errorf("expect main_exit to be the 5th instruction, got 0x%x", program.Labels["main_exit"])
+	}
+
+	lw := program.Text[2]
+	if !(lw.Op == "lw" && lw.Rt == registers["t0"] && lw.Rs == registers["fp"] && lw.Imm == -4) {
+		t.Errorf("expect `lw $t0, -4($fp)`, got %+v", lw)
+	}
+}
+
+func TestAssembleError(t *testing.T) {
+	sources := []string{
+		"main:\nfoo $t0, $t1",
+		"main:\nj nowhere",
+		"main:\naddi $t0, $t1",
+		"main:\nli $t10, 1",
+		"main:\nmain:\njr $ra",
+		"main:\nlw $t0, nowhere",
+	}
+
+	for _, src := range sources {
+		_, err := Assemble(src)
+		if err == nil {
+			t.Errorf("expect error for %q, got nil", src)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	testRun(t, `
+main:
+li $v0, 1
+li $a0, 42
+syscall
+li $v0, 11
+li $a0, 33
+syscall
+jr $ra
+`, "42!")
+
+	// sum of 1..10 with a stack frame and a call
+	testRun(t, `
+.text
+.globl main
+sum:
+addi $sp, $sp, -8
+sw $ra, 4($sp)
+sw $fp, 0($sp)
+addi $fp, $sp, 4
+li $v0, 0
+sum_loop:
+beq $a0, $zero, sum_exit
+add $v0, $v0, $a0
+addi $a0, $a0, -1
+j sum_loop
+sum_exit:
+lw $fp, 0($sp)
+lw $ra, 4($sp)
+addi $sp, $sp, 8
+jr $ra
+
+main:
+addi $sp, $sp, -4
+sw $ra, 0($sp)
+li $a0, 10
+jal sum
+sw $v0, -4($gp)
+li $v0, 1
+lw $a0, -4($gp)
+syscall
+lw $ra, 0($sp)
+addi $sp, $sp, 4
+jr $ra
+`, "55")
+
+	testRun(t, `
+main:
+li $t0, 7
+li $t1, -2
+div $t2, $t0, $t1
+mul $t2, $t2, $t1
+sub $t2, $t0, $t2
+slt $t3, $t1, $t0
+slti $t4, $t0, 7
+add $a0, $t2, $t3
+add $a0, $a0, $t4
+li $v0, 1
+syscall
+li $v0, 10
+syscall
+li $v0, 11
+syscall
+`, "2")
+}
+
+func TestRunBitwise(t *testing.T) {
+	testRun(t, `
+main:
+li $t0, 12
+li $t1, 10
+li $t2, -17
+li $t3, 5
+li $v0, 1
+rem $a0, $t2, $t3
+syscall
+and $a0, $t0, $t1
+syscall
+or $a0, $t0, $t1
+syscall
+xor $a0, $t0, $t1
+syscall
+nor $a0, $t0, $zero
+syscall
+li $t3, 2
+sllv $a0, $t0, $t3
+syscall
+srav $a0, $t2, $t3
+syscall
+jr $ra
+`, "-28146-1348-5")
+}
+
+func TestRunUnsigned(t *testing.T) {
+	testRun(t, `
+main:
+li $t0, -1
+li $t1, 10
+li $t2, 28
+li $v0, 1
+divu $a0, $t0, $t1
+syscall
+remu $a0, $t0, $t1
+syscall
+sltu $a0, $t1, $t0
+syscall
+slt $a0, $t1, $t0
+syscall
+srlv $a0, $t0, $t2
+syscall
+jr $ra
+`, "42949672951015")
+}
+
+func TestRunDataAddress(t *testing.T) {
+	testRun(t, `
+.data
+hello: .asciiz "hi"
+.data 0x10007ff8
+.byte 65, 66
+.rdata 0x10007ffc
+answer: .word 42
+.data 0x10008000
+count:
+.text
+main:
+sw $zero, -12($gp)
+li $v0, 1
+lw $a0, -4($gp)
+syscall
+li $v0, 11
+lb $a0, -7($gp)
+syscall
+li $t0, 7
+sw $t0, count
+li $v0, 1
+lw $a0, count
+syscall
+lw $a0, answer
+syscall
+jr $ra
+`, "42B742")
+
+	_, err := Assemble(".data\nhello: .asciiz \"hi\"\n.data 0x10000001\n.word 1")
+	if err == nil {
+		t.Error("expect overlapping data error, got nil")
+	}
+}
+
+func TestRunString(t *testing.T) {
+	testRun(t, `
+.data
+hello: .asciiz "hello # world\n"
+colon: .asciiz "a:b"
+.text
+main:
+li $v0, 4
+la $a0, hello
+syscall
+la $t0, colon
+lb $a0, 1($t0)
+li $v0, 11
+syscall
+jr $ra
+`, "hello # world\n:")
+}
+
+func TestRunJumpTable(t *testing.T) {
+	testRun(t, `
+.text
+main:
+li $t0, 1
+sltiu $t1, $t0, 2
+beq $t1, $zero, fail
+sll $t0, $t0, 2
+la $t1, table
+add $t1, $t1, $t0
+lw $t0, 0($t1)
+jr $t0
+.data
+pad: .asciiz "x"
+table: .word fail, ok
+.text
+fail:
+jr $ra
+ok:
+li $a0, 1
+li $v0, 1
+syscall
+jr $ra
+`, "1")
+}
+
+func TestRunIndirectCall(t *testing.T) {
+	testRun(t, `
+.text
+double:
+add $v0, $a0, $a0
+jr $ra
+main:
+addi $sp, $sp, -4
+sw $ra, 0($sp)
+la $t0, double
+li $a0, 21
+jalr $t0
+add $a0, $v0, $zero
+li $v0, 1
+syscall
+lw $ra, 0($sp)
+addi $sp, $sp, 4
+jr $ra
+`, "42")
+}
+
+func testRun(t *testing.T, src string, expected string) {
+	var output bytes.Buffer
+	err := Run(src, &output)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if output.String() != expected {
+		t.Errorf("expect `%v`, got `%v`", expected, output.String())
+	}
+}
+
+func TestRunError(t *testing.T) {
+	sources := []string{
+		// no main
+		"foo:\njr $ra",
+		// division by zero
+		"main:\nli $t0, 0\ndiv $t1, $t1, $t0\njr $ra",
+		// unaligned
+		"main:\nlw $t0, 2($sp)\njr $ra",
+		// read-only data
+		".rdata 0x10007ffc\n.word 1\n.text\nmain:\nsw $zero, -4($gp)\njr $ra",
+		".rdata 0x10007ffc\n.word 1\n.text\nmain:\nsb $zero, -1($gp)\njr $ra",
+	}
+
+	for _, src := range sources {
+		err := Run(src, &bytes.Buffer{})
+		if err == nil {
+			t.Errorf("expect error for %q, got nil", src)
+		}
+	}
+
+	program, _ := Assemble("main:\nj main")
+	machine := NewMachine(program, &bytes.Buffer{})
+	machine.StepLimit = 100
+
+	err := machine.Run()
+	if !(err != nil && strings.Contains(err.Error(), "step limit")) {
+		t.Errorf("expect step limit error, got %v", err)
+	}
+}
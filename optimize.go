@@ -1,5 +1,7 @@
 package main
 
+import "strings"
+
 type DataflowBlock struct {
 	Name       string
 	Statements []IRStatement
@@ -30,23 +32,147 @@ func (state BlockState) Equal(anotherState BlockState) bool {
 	return true
 }
 
+// const globals with initial values, whose loads are folded into the values
+var readOnlyDeclarations = map[*Symbol]*IRVariableDeclaration{}
+
 func Optimize(program *IRProgram) *IRProgram {
-	for i, f := range program.Functions {
-		statements := flatStatement(f)
+	readOnlyDeclarations = map[*Symbol]*IRVariableDeclaration{}
+	for _, d := range program.Declarations {
+		if d.IsReadOnly() {
+			readOnlyDeclarations[d.Var] = d
+		}
+	}
 
-		blocks := splitStatementsIntoBlocks(statements)
+	for i, f := range program.Functions {
+		allStatementState := analyzeReachingDefinitions(f)
+		f = transformByConstantFolding(f, allStatementState)
 
-		buildDataflowGraph(blocks)
-		blockOut := searchReachingDefinitions(blocks)
-		allStatementState := reachingDefinitionsOfStatements(blocks, blockOut, statements)
+		// loads from read-only arrays are known once their addresses are folded
+		if changed := transformByReadOnlyLoads(f, allStatementState); changed {
+			allStatementState = analyzeReachingDefinitions(f)
+			f = transformByConstantFolding(f, allStatementState)
+		}
 
-		program.Functions[i] = transformByConstantFolding(program.Functions[i], allStatementState)
-		program.Functions[i] = transformByDeadCodeElimination(program.Functions[i], allStatementState)
+		program.Functions[i] = transformByDeadCodeElimination(f, allStatementState)
 	}
 
 	return program
 }
 
+func analyzeReachingDefinitions(f *IRFunctionDefinition) map[IRStatement]BlockState {
+	statements := flatStatement(f)
+
+	blocks := splitStatementsIntoBlocks(statements)
+
+	buildDataflowGraph(blocks)
+	blockOut := searchReachingDefinitions(blocks)
+
+	return reachingDefinitionsOfStatements(blocks, blockOut, statements)
+}
+
+// transformByReadOnlyLoads replaces `x = *p` with `x = value` if p is a constant address in read-only data
+func transformByReadOnlyLoads(f *IRFunctionDefinition, allStatementState map[IRStatement]BlockState) bool {
+	changed := false
+
+	Traverse(f, func(statement IRStatement) IRStatement {
+		s, isRead := statement.(*IRReadStatement)
+		if !isRead {
+			return statement
+		}
+
+		definitions := allStatementState[s][s.Src]
+		if len(definitions) != 1 {
+			return statement
+		}
+
+		definition, isAssignment := definitions[0].(*IRAssignmentStatement)
+		if !isAssignment {
+			return statement
+		}
+
+		symbol, offset, isAddress := constantAddress(definition.Expression)
+		if !isAddress {
+			return statement
+		}
+
+		// a cast pointer may read a part of the element
+		size := s.Size
+		if size == 0 {
+			size = 4
+		}
+
+		if size != scalarType(symbol.Type).ByteSize() {
+			return statement
+		}
+
+		isConstant, value := readOnlyValue(symbol, offset)
+		if !isConstant {
+			return statement
+		}
+
+		changed = true
+		return &IRAssignmentStatement{Var: s.Dest, Expression: &IRNumberExpression{Value: value}}
+	})
+
+	return changed
+}
+
+// constantAddress returns the variable and the offset of `&a + offset`, where an array `a` is its address
+func constantAddress(expression IRExpression) (*Symbol, int, bool) {
+	switch e := expression.(type) {
+	case *IRAddressExpression:
+		return e.Var, 0, true
+
+	case *IRVariableExpression:
+		if _, isArray := e.Var.Type.(ArrayType); isArray {
+			return e.Var, 0, true
+		}
+
+	case *IRBinaryExpression:
+		if e.Operator != "+" {
+			return nil, 0, false
+		}
+
+		symbol, offset, isAddress := constantAddress(e.Left)
+		number, isNumber := e.Right.(*IRNumberExpression)
+		if !isAddress || !isNumber {
+			symbol, offset, isAddress = constantAddress(e.Right)
+			number, isNumber = e.Left.(*IRNumberExpression)
+		}
+
+		if isAddress && isNumber {
+			return symbol, offset + number.Value, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// readOnlyValue returns the initial value at offset of a read-only variable
+func readOnlyValue(symbol *Symbol, offset int) (bool, int) {
+	d := readOnlyDeclarations[symbol]
+	if d == nil {
+		return false, 0
+	}
+
+	size := scalarType(d.Var.Type).ByteSize()
+	if size == 0 || offset < 0 || offset%size != 0 || offset/size >= len(d.Init) {
+		return false, 0
+	}
+
+	number, isNumber := d.Init[offset/size].(*IRNumberExpression)
+	if !isNumber {
+		return false, 0
+	}
+
+	if size == 1 {
+		// lb sign-extends the byte
+		return true, int(int8(number.Value))
+	}
+
+	return true, number.Value
+}
+
 func transformByConstantFolding(f *IRFunctionDefinition, allStatementState map[IRStatement]BlockState) *IRFunctionDefinition {
 	traversed := Traverse(f, func(statement IRStatement) IRStatement {
 		foldConstantStatement(statement, allStatementState)
@@ -103,6 +229,16 @@ func transformByDeadCodeElimination(f *IRFunctionDefinition, allStatementState m
 					markAsUsed(s, argVar)
 				}
 
+			case *IRIndirectCallStatement:
+				if s.Dest.IsGlobal() {
+					used[s] = true
+				}
+
+				markAsUsed(s, s.Target)
+				for _, argVar := range s.Vars {
+					markAsUsed(s, argVar)
+				}
+
 			case *IRSystemCallStatement:
 				markAsUsed(s, s.Var)
 
@@ -111,6 +247,9 @@ func transformByDeadCodeElimination(f *IRFunctionDefinition, allStatementState m
 
 			case *IRIfStatement:
 				markAsUsed(s, s.Var)
+
+			case *IRJumpTableStatement:
+				markAsUsed(s, s.Var)
 			}
 
 			return statement
@@ -144,6 +283,8 @@ func removeUnusedVariableDeclaration(f *IRFunctionDefinition) *IRFunctionDefinit
 			used[s.Dest] = true
 		case *IRCallStatement:
 			used[s.Dest] = true
+		case *IRIndirectCallStatement:
+			used[s.Dest] = true
 		}
 
 		return statement
@@ -154,8 +295,10 @@ func removeUnusedVariableDeclaration(f *IRFunctionDefinition) *IRFunctionDefinit
 		case *IRCompoundStatement:
 			newDeclarations := []*IRVariableDeclaration{}
 			for _, d := range s.Declarations {
+				// arrays and structs are accessed via their address
 				_, isArrayType := d.Var.Type.(ArrayType)
-				if used[d.Var] || isArrayType {
+				_, isStructType := d.Var.Type.(*StructType)
+				if used[d.Var] || isArrayType || isStructType {
 					newDeclarations = append(newDeclarations, d)
 				}
 			}
@@ -247,11 +390,26 @@ func reachingDefinitionsOfStatements(blocks []*DataflowBlock, blockOut map[*Data
 	return allStatementState
 }
 
+// statements being folded, to stop at definitions which depend on themselves through a loop
+var foldingStatements = map[IRStatement]bool{}
+
 func foldConstantStatement(statement IRStatement, allStatementState map[IRStatement]BlockState) (bool, int) {
 	switch s := statement.(type) {
 	case *IRAssignmentStatement:
+		if foldingStatements[s] {
+			return false, 0
+		}
+
+		foldingStatements[s] = true
+		defer delete(foldingStatements, s)
+
 		isConstant, value := foldConstantExpression(s, s.Expression, allStatementState)
 		if isConstant {
+			if s.Var.Type != nil && s.Var.Type.ByteSize() == 1 {
+				// char
+				value = int(int8(value))
+			}
+
 			s.Expression = &IRNumberExpression{Value: value}
 			return true, value
 		}
@@ -267,6 +425,19 @@ func foldConstantExpression(statement IRStatement, expression IRExpression, allS
 
 	case *IRVariableExpression:
 		symbol := e.Var
+
+		// a read-only array is its address
+		if _, isArray := symbol.Type.(ArrayType); !isArray {
+			if isReadOnly, value := readOnlyValue(symbol, 0); isReadOnly {
+				return true, value
+			}
+		}
+
+		// parameters and globals have unknown values at the entry of the function
+		if symbol.Kind == "parm" || symbol.IsGlobal() {
+			return false, 0
+		}
+
 		definitionOfVar := allStatementState[statement][symbol]
 		if len(definitionOfVar) == 1 && definitionOfVar[0] != statement {
 			return foldConstantStatement(definitionOfVar[0], allStatementState)
@@ -287,70 +458,162 @@ func foldConstantExpression(statement IRStatement, expression IRExpression, allS
 		}
 
 		if leftIsConstant && rightIsConstant {
-			switch e.Operator {
-			case "+":
-				return true, leftValue + rightValue
+			return calculate(e.Operator, leftValue, rightValue)
+		}
+
+		return false, 0
+	}
 
-			case "-":
-				return true, leftValue - rightValue
+	return false, 0
+}
 
-			case "*":
-				return true, leftValue * rightValue
+// calculate applies a binary operator to constants, which wraps around at 32 bits like MIPS
+// operators with the suffix `u` like `/u` treat the operands as unsigned
+// it returns false if the value is undefined at compile time (e.g. division by zero)
+func calculate(operator string, left int, right int) (bool, int) {
+	left, right = wrap(left), wrap(right)
 
-			case "/":
-				return true, leftValue / rightValue
+	switch operator {
+	case "+":
+		return true, wrap(left + right)
 
-			case "<":
-				value := 0
-				if leftValue < rightValue {
-					value = 1
-				}
-				return true, value
+	case "-":
+		return true, wrap(left - right)
 
-			case ">":
-				value := 0
-				if leftValue > rightValue {
-					value = 1
-				}
-				return true, value
+	case "*":
+		return true, wrap(left * right)
 
-			case "<=":
-				value := 0
-				if leftValue <= rightValue {
-					value = 1
-				}
-				return true, value
+	case "/":
+		if right == 0 {
+			return false, 0
+		}
+		return true, wrap(left / right)
 
-			case ">=":
-				value := 0
-				if leftValue >= rightValue {
-					value = 1
-				}
-				return true, value
+	case "%":
+		if right == 0 {
+			return false, 0
+		}
+		return true, wrap(left % right)
 
-			case "==":
-				value := 0
-				if leftValue == rightValue {
-					value = 1
-				}
-				return true, value
+	case "/u", "%u", ">>u", "<u", ">u", "<=u", ">=u":
+		return calculateUnsigned(strings.TrimSuffix(operator, "u"), uint32(left), uint32(right))
 
-			case "!=":
-				value := 0
-				if leftValue != rightValue {
-					value = 1
-				}
-				return true, value
+	case "&":
+		return true, left & right
 
-			}
+	case "|":
+		return true, left | right
+
+	case "^":
+		return true, left ^ right
+
+	case "nor":
+		return true, ^(left | right)
 
-			panic("unexpected operator: " + e.Operator)
+	case "!":
+		value := 0
+		if left == 0 {
+			value = 1
 		}
+		return true, value
 
-		return false, 0
+	case "<<":
+		// shift amounts are taken modulo 32 like sllv and srav
+		return true, int(int32(left) << uint(right&31))
+
+	case ">>":
+		return true, int(int32(left) >> uint(right&31))
+
+	case "<":
+		value := 0
+		if left < right {
+			value = 1
+		}
+		return true, value
+
+	case ">":
+		value := 0
+		if left > right {
+			value = 1
+		}
+		return true, value
+
+	case "<=":
+		value := 0
+		if left <= right {
+			value = 1
+		}
+		return true, value
+
+	case ">=":
+		value := 0
+		if left >= right {
+			value = 1
+		}
+		return true, value
+
+	case "==":
+		value := 0
+		if left == right {
+			value = 1
+		}
+		return true, value
+
+	case "!=":
+		value := 0
+		if left != right {
+			value = 1
+		}
+		return true, value
 	}
 
-	return false, 0
+	panic("unexpected operator: " + operator)
+}
+
+func calculateUnsigned(operator string, left uint32, right uint32) (bool, int) {
+	switch operator {
+	case "/":
+		if right == 0 {
+			return false, 0
+		}
+		return true, wrap(int(left / right))
+
+	case "%":
+		if right == 0 {
+			return false, 0
+		}
+		return true, wrap(int(left % right))
+
+	case ">>":
+		return true, wrap(int(left >> (right & 31)))
+
+	case "<":
+		return true, boolToInt(left < right)
+
+	case ">":
+		return true, boolToInt(left > right)
+
+	case "<=":
+		return true, boolToInt(left <= right)
+
+	case ">=":
+		return true, boolToInt(left >= right)
+	}
+
+	panic("unexpected operator: " + operator + "u")
+}
+
+// wrap truncates value to a signed 32-bit integer
+func wrap(value int) int {
+	return int(int32(value))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
 }
 
 func blockIn(blockOut map[*DataflowBlock]BlockState, block *DataflowBlock) BlockState {
@@ -405,6 +668,9 @@ func analyzeReachingDefinition(statement IRStatement, inState BlockState) BlockS
 	case *IRCallStatement:
 		inState[s.Dest] = []IRStatement{s}
 
+	case *IRIndirectCallStatement:
+		inState[s.Dest] = []IRStatement{s}
+
 	}
 
 	return inState
@@ -423,7 +689,7 @@ func splitStatementsIntoBlocks(statements []IRStatement) []*DataflowBlock {
 
 			block = &DataflowBlock{Statements: []IRStatement{s}}
 
-		case *IRIfStatement, *IRGotoStatement, *IRReturnStatement:
+		case *IRIfStatement, *IRGotoStatement, *IRJumpTableStatement, *IRReturnStatement:
 			// out
 			block.Statements = append(block.Statements, s)
 			blocks = append(blocks, block)
@@ -476,6 +742,12 @@ func buildDataflowGraph(blocks []*DataflowBlock) *DataflowBlock {
 				}
 			}
 
+		case *IRJumpTableStatement:
+			// jump table block -> case blocks, default block
+			for _, name := range append(s.Labels, s.Default) {
+				block.AddEdge(findBlockByLabel(blocks, name))
+			}
+
 		case *IRReturnStatement:
 			// return block -> end block
 			block.AddEdge(endBlock)
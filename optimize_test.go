@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+
+	"github.com/uiureo/small-c/mips"
 )
 
 func TestExtractVarsFromExpression(t *testing.T) {
@@ -23,3 +27,108 @@ func TestExtractVarsFromExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculate(t *testing.T) {
+	cases := []struct {
+		Operator string
+		Left     int
+		Right    int
+		Value    int
+	}{
+		{"%", -17, 5, -2},
+		{"&", 12, 10, 8},
+		{"|", 12, 10, 14},
+		{"^", 12, 10, 6},
+		{"nor", 12, 0, -13},
+		{"<<", 1, 33, 2},
+		{"<<", 1, 31, -2147483648},
+		{">>", -32, 2, -8},
+		{"+", 2147483647, 1, -2147483648},
+		{"*", 65536, 65536, 0},
+		{"/u", -2, 2, 2147483647},
+		{"%u", -1, 10, 5},
+		{">>u", -16, 28, 15},
+		{"<u", -1, 1, 0},
+		{">=u", -1, 1, 1},
+	}
+
+	for _, c := range cases {
+		ok, value := calculate(c.Operator, c.Left, c.Right)
+		if !ok || value != c.Value {
+			t.Errorf("expect %v %v %v == %v, got %v", c.Left, c.Operator, c.Right, c.Value, value)
+		}
+	}
+
+	if ok, _ := calculate("%", 1, 0); ok {
+		t.Error("expect remainder by zero not to be folded")
+	}
+}
+
+func TestOptimizeLoopDefinitions(t *testing.T) {
+	// `n` has its argument value in the first iteration, so `x = n` must not be folded to 0
+	// and `s++` depends on itself through the loop
+	code, errs := CompileSource(`
+    int f(int n) {
+      int x;
+      while (n) {
+        x = n;
+        n = 0;
+      }
+      return x;
+    }
+
+    int main() {
+      char *s;
+      s = "ab";
+      while (*s) {
+        putchar(*s++);
+      }
+      print(f(7));
+    }
+  `, true)
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	var output bytes.Buffer
+	if err := mips.Run(code, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != "ab7" {
+		t.Errorf("expect `ab7`, got `%v`", output.String())
+	}
+}
+
+func TestOptimizeReadOnlyLoads(t *testing.T) {
+	code, errs := CompileSource(`
+    const int table[4] = {10, 20, 30, 40};
+    const char c = 300;
+    int mutable[2] = {1, 2};
+
+    int main() {
+      int i;
+      i = 2;
+      print(table[i] + table[1] + c + mutable[1]);
+    }
+  `, true)
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// the loads from table and c are folded, but mutable is still read
+	if !strings.Contains(code, ", 94\n") || !strings.Contains(code, ".rdata") {
+		t.Errorf("expect the loads from read-only data to be folded, got\n%v", code)
+	}
+
+	var output bytes.Buffer
+	if err := mips.Run(code, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != "96" {
+		t.Errorf("expect `96`, got `%v`", output.String())
+	}
+}
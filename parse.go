@@ -6,13 +6,23 @@ import (
 
 // Parse returns ast
 func Parse(src string) ([]Statement, error) {
+	return ParseSource(&Source{Text: src})
+}
+
+// ParseSource parses preprocessed code, and positions refer to the original files
+func ParseSource(source *Source) ([]Statement, error) {
 	l := new(Lexer)
-	l.Init(src)
+	l.Init(source.Text)
+	l.lines = source.Lines
 	yyErrorVerbose = true
 
 	fail := yyParse(l)
+	if len(l.errs) > 0 {
+		return nil, l.errs[0]
+	}
+
 	if fail == 1 {
-		err := fmt.Errorf("%d:%d: %s", l.pos.Line, l.pos.Column, l.errMessage)
+		err := fmt.Errorf("%s: %s", formatPosition(l.pos), l.errMessage)
 
 		return nil, err
 	}
@@ -43,19 +53,40 @@ func Walk(statement Statement) Statement {
 
 		return s
 
+	case *Declaration:
+		for _, declarator := range s.Declarators {
+			declarator.Initializer = WalkExpression(declarator.Initializer)
+		}
+
+		return s
+
+	case *EnumDeclaration:
+		for _, enumerator := range s.Enumerators {
+			enumerator.Value = WalkExpression(enumerator.Value)
+		}
+
+		return s
+
+	case *TypedefDeclaration:
+		if s.Definition != nil {
+			s.Definition = Walk(s.Definition)
+		}
+
+		return s
+
 	case *ForStatement:
 		// for (init; cond; loop) s
 		// => init; while (cond) { s; loop; }
+		// loop is kept apart from s so that `continue` can jump to it
 
 		var statements []Statement
 		if s.Init != nil {
 			statements = append(statements, &ExpressionStatement{Value: WalkExpression(s.Init)})
 		}
 
-		body := Walk(s.Statement)
-		whileBody := []Statement{body}
+		var loop Statement
 		if s.Loop != nil {
-			whileBody = append(whileBody, &ExpressionStatement{Value: WalkExpression(s.Loop)})
+			loop = &ExpressionStatement{Value: WalkExpression(s.Loop)}
 		}
 
 		var condition Expression
@@ -69,9 +100,8 @@ func Walk(statement Statement) Statement {
 			&WhileStatement{
 				pos:       s.Pos(),
 				Condition: condition,
-				Statement: &CompoundStatement{
-					Statements: whileBody,
-				},
+				Statement: Walk(s.Statement),
+				Loop:      loop,
 			},
 		)
 
@@ -83,6 +113,18 @@ func Walk(statement Statement) Statement {
 		s.Condition = WalkExpression(s.Condition)
 		s.Statement = Walk(s.Statement)
 
+	case *DoWhileStatement:
+		s.Statement = Walk(s.Statement)
+		s.Condition = WalkExpression(s.Condition)
+
+	case *SwitchStatement:
+		s.Value = WalkExpression(s.Value)
+		s.Statement = Walk(s.Statement)
+
+	case *CaseStatement:
+		s.Value = WalkExpression(s.Value)
+		s.Statement = Walk(s.Statement)
+
 	case *IfStatement:
 		s.Condition = WalkExpression(s.Condition)
 		s.TrueStatement = Walk(s.TrueStatement)
@@ -112,6 +154,7 @@ func WalkExpression(expression Expression) Expression {
 		return e
 
 	case *FunctionCallExpression:
+		e.Identifier = WalkExpression(e.Identifier)
 		e.Argument = WalkExpression(e.Argument)
 
 		return e
@@ -131,6 +174,13 @@ func WalkExpression(expression Expression) Expression {
 				Operator: "-",
 				Right:    e.Value,
 			}
+		} else if e.Operator == "++" || e.Operator == "--" {
+			// ++a  =>  a += 1
+			return &BinaryExpression{
+				Left:     e.Value,
+				Operator: e.Operator[:1] + "=",
+				Right:    &NumberExpression{pos: e.Pos(), Value: "1"},
+			}
 		} else if e.Operator == "&" {
 			// &(*e) -> e
 			switch value := e.Value.(type) {
@@ -143,12 +193,53 @@ func WalkExpression(expression Expression) Expression {
 
 		return e
 
+	case *PostfixExpression:
+		e.Value = WalkExpression(e.Value)
+
+		return e
+
+	case *CastExpression:
+		e.Value = WalkExpression(e.Value)
+
+		return e
+
+	case *SizeofExpression:
+		e.Value = WalkExpression(e.Value)
+
+		return e
+
+	case *InitializerList:
+		for i, value := range e.Values {
+			e.Values[i] = WalkExpression(value)
+		}
+
+		return e
+
+	case *ConditionalExpression:
+		e.Condition = WalkExpression(e.Condition)
+		e.TrueValue = WalkExpression(e.TrueValue)
+		e.FalseValue = WalkExpression(e.FalseValue)
+
+		return e
+
+	case *MemberExpression:
+		e.Target = WalkExpression(e.Target)
+
+		if e.Operator == "->" {
+			// p->m  =>  (*p).m
+			e.Target = &UnaryExpression{pos: e.Target.Pos(), Operator: "*", Value: e.Target}
+			e.Operator = "."
+		}
+
+		return e
+
 	case *ArrayReferenceExpression:
 		// a[100]  =>  *(a + 100)
 		e.Target = WalkExpression(e.Target)
 		e.Index = WalkExpression(e.Index)
 
 		return &UnaryExpression{
+			pos:      e.Pos(),
 			Operator: "*",
 			Value: &BinaryExpression{
 				Left:     e.Target,
@@ -130,6 +130,28 @@ func TestParseWhileStatement(t *testing.T) {
 	}
 }
 
+func TestParseDoWhileStatement(t *testing.T) {
+	statements, err := Parse(`
+    int main() {
+      do a = a - 1; while (a);
+      do {
+        continue;
+      } while (a > 0);
+    }
+  `)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	switch mainStatements(statements)[0].(type) {
+	case *DoWhileStatement:
+	default:
+		t.Error("expected DoWhileStatement")
+	}
+}
+
 func TestParseForStatement(t *testing.T) {
 	statements, err := Parse(`
     int main() {
@@ -155,6 +177,22 @@ func TestParseForStatement(t *testing.T) {
 	}
 }
 
+func TestWalkForStatement(t *testing.T) {
+	statements, _ := Parse(`
+    int main() {
+      for (i = 0; i < 10; i = i + 1) {
+        continue;
+      }
+    }
+  `)
+
+	walked := Walk(mainStatements(statements)[0]).(*CompoundStatement)
+	while, ok := walked.Statements[1].(*WhileStatement)
+	if !(ok && while.Loop != nil) {
+		t.Errorf("expect while statement with loop step, got %v", walked.Statements)
+	}
+}
+
 func mainStatements(statements []Statement) []Statement {
 	main := statements[0].(*FunctionDefinition)
 
@@ -175,6 +213,95 @@ func TestParseUnaryExpression(t *testing.T) {
 	}
 }
 
+func TestParseBitwisePrecedence(t *testing.T) {
+	statements, err := Parse(`
+    int main() {
+      a = b | c ^ d & e == f << g + h % i;
+    }
+  `)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "(b | (c ^ (d & (e == (f << (g + (h % i)))))))"
+
+	body := statements[0].(*FunctionDefinition).Statement.(*CompoundStatement)
+	assignment := body.Statements[0].(*ExpressionStatement).Value.(*BinaryExpression)
+	actual := formatExpression(assignment.Right)
+	if actual != expected {
+		t.Errorf("expect %v, got %v", expected, actual)
+	}
+}
+
+func TestParseCast(t *testing.T) {
+	statements, err := Parse(`
+    int main() {
+      a = (char) b + (struct node**) (c) * d;
+    }
+  `)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "(((char) b) + (((struct node**) c) * d))"
+
+	body := statements[0].(*FunctionDefinition).Statement.(*CompoundStatement)
+	assignment := body.Statements[0].(*ExpressionStatement).Value.(*BinaryExpression)
+	actual := formatExpression(assignment.Right)
+	if actual != expected {
+		t.Errorf("expect %v, got %v", expected, actual)
+	}
+}
+
+func TestParseSizeof(t *testing.T) {
+	statements, err := Parse(`
+    int main() {
+      a = sizeof(int) * 2 - sizeof (a) - sizeof *p;
+    }
+  `)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "((((sizeof int) * 2) - (sizeof a)) - (sizeof (* p)))"
+
+	body := statements[0].(*FunctionDefinition).Statement.(*CompoundStatement)
+	assignment := body.Statements[0].(*ExpressionStatement).Value.(*BinaryExpression)
+	actual := formatExpression(assignment.Right)
+	if actual != expected {
+		t.Errorf("expect %v, got %v", expected, actual)
+	}
+}
+
+func formatExpression(expression Expression) string {
+	switch e := expression.(type) {
+	case *BinaryExpression:
+		return "(" + formatExpression(e.Left) + " " + e.Operator + " " + formatExpression(e.Right) + ")"
+	case *CastExpression:
+		return "((" + e.TypeName + ") " + formatExpression(e.Value) + ")"
+	case *SizeofExpression:
+		if e.Value == nil {
+			return "(sizeof " + e.TypeName + ")"
+		}
+
+		return "(sizeof " + formatExpression(e.Value) + ")"
+	case *UnaryExpression:
+		return "(" + e.Operator + " " + formatExpression(e.Value) + ")"
+	case *NumberExpression:
+		return e.Value
+	case *IdentifierExpression:
+		return e.Name
+	}
+
+	return "?"
+}
+
 func TestWalkExpression(t *testing.T) {
 	{
 		e := WalkExpression(&UnaryExpression{
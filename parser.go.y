@@ -20,17 +20,31 @@ import (
   statements []Statement
 
   parameter_declaration *ParameterDeclaration
+
+  sizes []int
+  type_names []string
+  enumerators []*Enumerator
+  enumerator *Enumerator
 }
 
 %type<expression> expression optional_expression identifier_expression identifier
-%type<expression> add_expression mult_expression assign_expression primary_expression logical_or_expression logical_and_expression equal_expression relation_expression unary_expression postfix_expression
-%type<expressions> parameters optional_parameters
+%type<expression> initializer conditional_expression add_expression mult_expression assign_expression primary_expression logical_or_expression logical_and_expression or_expression xor_expression and_expression equal_expression shift_expression relation_expression unary_expression postfix_expression
+%type<expressions> parameters optional_parameters initializers
 %type<statements> statements declarations optional_statements optional_declarations program
-%type<statement> statement compound_statement external_declaration declaration function_definition function_prototype
-%type<declarator> declarator
+%type<statement> statement compound_statement external_declaration declaration function_definition function_prototype struct_declaration enum_declaration
+%type<enumerators> enumerators enumerator_list
+%type<enumerator> enumerator
+%type<declarator> declarator direct_declarator function_pointer_declarator
 %type<declarators> declarators
 %type<parameter_declaration> parameter_declaration
-%token<token> NUMBER CHAR IDENT TYPE IF LOGICAL_OR LOGICAL_AND RETURN EQL NEQ GEQ LEQ ELSE WHILE FOR '-' '*' '&' '{'
+%type<sizes> parameter_sizes
+%type<token> type_specifier unqualified_type_specifier type_name parameter_type storage_class
+%type<type_names> parameter_types optional_parameter_types
+%token<token> NUMBER CHAR STRING IDENT TYPE IF LOGICAL_OR LOGICAL_AND RETURN EQL NEQ GEQ LEQ ELSE WHILE DO FOR BREAK CONTINUE SWITCH CASE DEFAULT STRUCT ARROW LSHIFT RSHIFT INC DEC ASSIGN_OP SIZEOF ENUM TYPEDEF STATIC EXTERN CONST UNSIGNED '-' '*' '&' '~' '!' '{' '('
+
+// sizeof(int) * 2 is a multiplication, not sizeof of a cast
+%left '-' '*' '&'
+%nonassoc SIZEOF
 
 %%
 
@@ -62,10 +76,120 @@ declarations
   }
 
 declaration
-  : TYPE declarators ';'
+  : type_specifier declarators ';'
   {
+    addIdentifiers(yylex, $2)
     $$ = &Declaration{ pos: $1.pos, VarType: $1.lit, Declarators: $2 }
   }
+  | storage_class type_specifier declarators ';'
+  {
+    addIdentifiers(yylex, $3)
+    $$ = &Declaration{ pos: $1.pos, Storage: $1.lit, VarType: $2.lit, Declarators: $3 }
+  }
+  | struct_declaration
+  | enum_declaration
+  | TYPEDEF type_specifier declarators ';'
+  {
+    addTypedefs(yylex, $3)
+    $$ = &TypedefDeclaration{ pos: $1.pos, VarType: $2.lit, Declarators: $3 }
+  }
+  | TYPEDEF STRUCT IDENT '{' declarations '}' declarators ';'
+  {
+    addTypedefs(yylex, $7)
+    definition := &StructDeclaration{ pos: $2.pos, Name: $3.lit, Members: $5 }
+    $$ = &TypedefDeclaration{ pos: $1.pos, VarType: "struct " + $3.lit, Declarators: $7, Definition: definition }
+  }
+  | TYPEDEF ENUM '{' enumerator_list '}' declarators ';'
+  {
+    addTypedefs(yylex, $6)
+    definition := &EnumDeclaration{ pos: $2.pos, Enumerators: $4 }
+    $$ = &TypedefDeclaration{ pos: $1.pos, VarType: "int", Declarators: $6, Definition: definition }
+  }
+  | TYPEDEF ENUM IDENT '{' enumerator_list '}' declarators ';'
+  {
+    addTypedefs(yylex, $7)
+    definition := &EnumDeclaration{ pos: $2.pos, Name: $3.lit, Enumerators: $5 }
+    $$ = &TypedefDeclaration{ pos: $1.pos, VarType: "enum " + $3.lit, Declarators: $7, Definition: definition }
+  }
+
+struct_declaration
+  : STRUCT IDENT '{' declarations '}' ';'
+  {
+    $$ = &StructDeclaration{ pos: $1.pos, Name: $2.lit, Members: $4 }
+  }
+
+enum_declaration
+  : ENUM '{' enumerator_list '}' ';'
+  {
+    $$ = &EnumDeclaration{ pos: $1.pos, Enumerators: $3 }
+  }
+  | ENUM IDENT '{' enumerator_list '}' ';'
+  {
+    $$ = &EnumDeclaration{ pos: $1.pos, Name: $2.lit, Enumerators: $4 }
+  }
+
+enumerator_list
+  : enumerators
+  | enumerators ','
+
+enumerators
+  : enumerator
+  {
+    $$ = []*Enumerator{ $1 }
+  }
+  | enumerators ',' enumerator
+  {
+    $$ = append($1, $3)
+  }
+
+enumerator
+  : IDENT
+  {
+    yylex.(*Lexer).AddIdentifier($1.lit)
+    $$ = &Enumerator{ Identifier: &IdentifierExpression{ pos: $1.pos, Name: $1.lit } }
+  }
+  | IDENT '=' conditional_expression
+  {
+    yylex.(*Lexer).AddIdentifier($1.lit)
+    $$ = &Enumerator{ Identifier: &IdentifierExpression{ pos: $1.pos, Name: $1.lit }, Value: $3 }
+  }
+
+type_specifier
+  : unqualified_type_specifier
+  | CONST unqualified_type_specifier
+  {
+    $$ = Token{ lit: "const " + $2.lit, pos: $1.pos }
+  }
+  | unqualified_type_specifier CONST
+  {
+    $$ = Token{ lit: "const " + $1.lit, pos: $1.pos }
+  }
+
+unqualified_type_specifier
+  : TYPE
+  | UNSIGNED
+  {
+    $$ = Token{ lit: "unsigned int", pos: $1.pos }
+  }
+  | UNSIGNED TYPE
+  {
+    $$ = Token{ lit: "unsigned " + $2.lit, pos: $1.pos }
+  }
+  | STRUCT IDENT
+  {
+    $$ = Token{ lit: "struct " + $2.lit, pos: $1.pos }
+  }
+  | ENUM IDENT
+  {
+    $$ = Token{ lit: "enum " + $2.lit, pos: $1.pos }
+  }
+
+type_name
+  : type_specifier
+  | type_name '*'
+  {
+    $$ = Token{ lit: $1.lit + "*", pos: $1.pos }
+  }
 
 declarators
   : declarator
@@ -78,34 +202,116 @@ declarators
   }
 
 declarator
+  : direct_declarator
+  | direct_declarator '=' initializer
+  {
+    $1.Initializer = $3
+    $$ = $1
+  }
+  | function_pointer_declarator
+  | function_pointer_declarator '=' initializer
+  {
+    $1.Initializer = $3
+    $$ = $1
+  }
+
+// int (*f)(int, int), int (*ops[4])(int)
+function_pointer_declarator
+  : '(' '*' direct_declarator ')' '(' optional_parameter_types ')'
+  {
+    $3.Identifier = &UnaryExpression{ pos: $2.pos, Operator: "*", Value: $3.Identifier }
+    $3.ParameterTypes = $6
+    $3.IsFunctionPointer = true
+    $$ = $3
+  }
+
+optional_parameter_types
+  : { $$ = nil }
+  | parameter_types
+
+parameter_types
+  : parameter_type
+  {
+    $$ = []string{ $1.lit }
+  }
+  | parameter_types ',' parameter_type
+  {
+    $$ = append($1, $3.lit)
+  }
+
+// parameter names of function pointers are only for documentation
+parameter_type
+  : type_name
+  | type_name IDENT
+
+direct_declarator
   : identifier_expression
   {
     $$ = &Declarator{ Identifier: $1 }
   }
-  | identifier_expression '[' NUMBER ']'
+  | direct_declarator '[' NUMBER ']'
+  {
+    i, _ := parseInteger($3.lit)
+    $1.Sizes = append($1.Sizes, i)
+    $$ = $1
+  }
+
+initializer
+  : assign_expression
+  | '{' initializers '}'
+  {
+    $$ = &InitializerList{ pos: $1.pos, Values: $2 }
+  }
+  | '{' initializers ',' '}'
+  {
+    $$ = &InitializerList{ pos: $1.pos, Values: $2 }
+  }
+
+initializers
+  : initializer
   {
-    i, _ := strconv.Atoi($3.lit)
-    $$ = &Declarator{ Identifier: $1, Size: i }
+    $$ = []Expression{ $1 }
+  }
+  | initializers ',' initializer
+  {
+    $$ = append($1, $3)
   }
 
 function_prototype
-  : TYPE identifier_expression '(' optional_parameters ')' ';'
+  : type_specifier identifier_expression '(' optional_parameters ')' ';'
   {
     $$ = &FunctionDefinition{ pos: $1.pos, TypeName: $1.lit, Identifier: $2, Parameters: $4 }
   }
+  | storage_class type_specifier identifier_expression '(' optional_parameters ')' ';'
+  {
+    $$ = &FunctionDefinition{ pos: $1.pos, Storage: $1.lit, TypeName: $2.lit, Identifier: $3, Parameters: $5 }
+  }
 
 function_definition
-  : TYPE identifier_expression '(' optional_parameters ')' compound_statement
+  : type_specifier identifier_expression '(' optional_parameters ')' compound_statement
   {
     $$ = &FunctionDefinition{ pos: $1.pos, TypeName: $1.lit, Identifier: $2, Parameters: $4, Statement: $6 }
   }
+  | storage_class type_specifier identifier_expression '(' optional_parameters ')' compound_statement
+  {
+    $$ = &FunctionDefinition{ pos: $1.pos, Storage: $1.lit, TypeName: $2.lit, Identifier: $3, Parameters: $5, Statement: $7 }
+  }
+
+storage_class
+  : STATIC
+  | EXTERN
 
 identifier_expression
   : identifier
-  | '*' identifier
+  | '*' identifier_expression
   {
     $$ = &UnaryExpression{ pos: $1.pos, Operator: "*", Value: $2 }
   }
+  | '*' CONST identifier_expression
+  {
+    // the pointer itself is const
+    $$ = &UnaryExpression{ pos: $1.pos, Operator: "* const", Value: $3 }
+  }
 
 optional_parameters
   : { $$ = nil }
@@ -122,10 +328,37 @@ parameters
   }
 
 parameter_declaration
-  : TYPE identifier_expression
+  : type_specifier identifier_expression
   {
+    yylex.(*Lexer).AddParameter(findIdentifierExpression($2).Name)
     $$ = &ParameterDeclaration{ pos: $1.pos, TypeName: $1.lit, Identifier: $2 }
   }
+  | type_specifier identifier_expression parameter_sizes
+  {
+    yylex.(*Lexer).AddParameter(findIdentifierExpression($2).Name)
+    $$ = &ParameterDeclaration{ pos: $1.pos, TypeName: $1.lit, Identifier: $2, Sizes: $3 }
+  }
+  | type_specifier function_pointer_declarator
+  {
+    yylex.(*Lexer).AddParameter(findIdentifierExpression($2.Identifier).Name)
+    $$ = &ParameterDeclaration{ pos: $1.pos, TypeName: $1.lit, Identifier: $2.Identifier, Sizes: $2.Sizes, ParameterTypes: $2.ParameterTypes, IsFunctionPointer: true }
+  }
+
+parameter_sizes
+  : '[' ']'
+  {
+    $$ = []int{ 0 }
+  }
+  | '[' NUMBER ']'
+  {
+    i, _ := parseInteger($2.lit)
+    $$ = []int{ i }
+  }
+  | parameter_sizes '[' NUMBER ']'
+  {
+    i, _ := parseInteger($3.lit)
+    $$ = append($1, i)
+  }
 
 compound_statement
   : '{' optional_declarations optional_statements '}'
@@ -173,6 +406,10 @@ statement
   {
     $$ = &WhileStatement{ pos: $1.pos, Condition: $3, Statement: $5 }
   }
+  | DO statement WHILE '(' expression ')' ';'
+  {
+    $$ = &DoWhileStatement{ pos: $1.pos, Statement: $2, Condition: $5 }
+  }
   | FOR '(' optional_expression ';' optional_expression ';' optional_expression ')' statement
   {
     $$ = &ForStatement{ pos: $1.pos, Init: $3, Condition: $5, Loop: $7, Statement: $9 }
@@ -181,6 +418,26 @@ statement
   {
     $$ = &ReturnStatement{ pos: $1.pos, Value: $2 }
   }
+  | SWITCH '(' expression ')' statement
+  {
+    $$ = &SwitchStatement{ pos: $1.pos, Value: $3, Statement: $5 }
+  }
+  | CASE logical_or_expression ':' statement
+  {
+    $$ = &CaseStatement{ pos: $1.pos, Value: $2, Statement: $4 }
+  }
+  | DEFAULT ':' statement
+  {
+    $$ = &CaseStatement{ pos: $1.pos, Statement: $3 }
+  }
+  | BREAK ';'
+  {
+    $$ = &BreakStatement{ pos: $1.pos }
+  }
+  | CONTINUE ';'
+  {
+    $$ = &ContinueStatement{ pos: $1.pos }
+  }
 
 optional_expression: { $$ = nil }
   | expression
@@ -201,11 +458,22 @@ expression
   }
 
 assign_expression
-  : logical_or_expression
+  : conditional_expression
   | logical_or_expression '=' assign_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: "=", Right: $3 }
   }
+  | logical_or_expression ASSIGN_OP assign_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: $2.lit, Right: $3 }
+  }
+
+conditional_expression
+  : logical_or_expression
+  | logical_or_expression '?' expression ':' conditional_expression
+  {
+    $$ = &ConditionalExpression{ Condition: $1, TrueValue: $3, FalseValue: $5 }
+  }
 
 logical_or_expression
   : logical_and_expression
@@ -215,12 +483,33 @@ logical_or_expression
   }
 
 logical_and_expression
-  : equal_expression
-  | logical_and_expression LOGICAL_AND equal_expression
+  : or_expression
+  | logical_and_expression LOGICAL_AND or_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: "&&", Right: $3}
   }
 
+or_expression
+  : xor_expression
+  | or_expression '|' xor_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: "|", Right: $3}
+  }
+
+xor_expression
+  : and_expression
+  | xor_expression '^' and_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: "^", Right: $3}
+  }
+
+and_expression
+  : equal_expression
+  | and_expression '&' equal_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: "&", Right: $3}
+  }
+
 equal_expression
   : relation_expression
   | equal_expression EQL relation_expression
@@ -233,24 +522,35 @@ equal_expression
   }
 
 relation_expression
-  : add_expression
-  | relation_expression '>' add_expression
+  : shift_expression
+  | relation_expression '>' shift_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: ">", Right: $3}
   }
-  | relation_expression '<' add_expression
+  | relation_expression '<' shift_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: "<", Right: $3}
   }
-  | relation_expression GEQ add_expression
+  | relation_expression GEQ shift_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: ">=", Right: $3}
   }
-  | relation_expression LEQ add_expression
+  | relation_expression LEQ shift_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: "<=", Right: $3}
   }
 
+shift_expression
+  : add_expression
+  | shift_expression LSHIFT add_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: "<<", Right: $3}
+  }
+  | shift_expression RSHIFT add_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: ">>", Right: $3}
+  }
+
 add_expression
   : mult_expression
   | add_expression '+' mult_expression
@@ -272,6 +572,10 @@ mult_expression
   {
     $$ = &BinaryExpression{ Left: $1, Operator: "/", Right: $3 }
   }
+  | mult_expression '%' unary_expression
+  {
+    $$ = &BinaryExpression{ Left: $1, Operator: "%", Right: $3 }
+  }
 
 unary_expression
   : postfix_expression
@@ -287,6 +591,34 @@ unary_expression
   {
     $$ = &UnaryExpression{ pos: $1.pos, Operator: "*", Value: $2 }
   }
+  | '~' unary_expression
+  {
+    $$ = &UnaryExpression{ pos: $1.pos, Operator: "~", Value: $2 }
+  }
+  | '!' unary_expression
+  {
+    $$ = &UnaryExpression{ pos: $1.pos, Operator: "!", Value: $2 }
+  }
+  | INC unary_expression
+  {
+    $$ = &UnaryExpression{ pos: $1.pos, Operator: "++", Value: $2 }
+  }
+  | DEC unary_expression
+  {
+    $$ = &UnaryExpression{ pos: $1.pos, Operator: "--", Value: $2 }
+  }
+  | '(' type_name ')' unary_expression
+  {
+    $$ = &CastExpression{ pos: $1.pos, TypeName: $2.lit, Value: $4 }
+  }
+  | SIZEOF unary_expression
+  {
+    $$ = &SizeofExpression{ pos: $1.pos, Value: $2 }
+  }
+  | SIZEOF '(' type_name ')' %prec SIZEOF
+  {
+    $$ = &SizeofExpression{ pos: $1.pos, TypeName: $3.lit }
+  }
 
 postfix_expression
   : primary_expression
@@ -294,15 +626,32 @@ postfix_expression
   {
     $$ = &ArrayReferenceExpression{ Target: $1, Index: $3 }
   }
-  | identifier '(' optional_expression ')'
+  | postfix_expression '(' optional_expression ')'
   {
     $$ = &FunctionCallExpression{ Identifier: $1, Argument: $3  }
   }
+  | postfix_expression '.' IDENT
+  {
+    $$ = &MemberExpression{ Target: $1, Operator: ".", Member: $3.lit }
+  }
+  | postfix_expression ARROW IDENT
+  {
+    $$ = &MemberExpression{ Target: $1, Operator: "->", Member: $3.lit }
+  }
+  | postfix_expression INC
+  {
+    $$ = &PostfixExpression{ Operator: "++", Value: $1 }
+  }
+  | postfix_expression DEC
+  {
+    $$ = &PostfixExpression{ Operator: "--", Value: $1 }
+  }
 
 primary_expression
   : NUMBER
   {
-    $$ = &NumberExpression{ pos: $1.pos, Value: $1.lit }
+    value, _ := parseInteger($1.lit)
+    $$ = &NumberExpression{ pos: $1.pos, Value: strconv.Itoa(wrap(value)), IsUnsigned: value > 0x7fffffff }
   }
   | identifier
   | '(' expression ')'
@@ -311,12 +660,14 @@ primary_expression
   }
   | CHAR
   {
-    literal := $1.lit
-    ch := literal[1:len(literal)-1][0]
-    i := int(ch)
-
+    i, _ := parseCharacter($1.lit)
     $$ = &NumberExpression{ pos: $1.pos, Value: strconv.Itoa(i) }
   }
+  | STRING
+  {
+    value, _ := unescape(literalBody($1.lit))
+    $$ = &StringExpression{ pos: $1.pos, Value: value }
+  }
 
 identifier
   : IDENT
@@ -0,0 +1,906 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// Source is a preprocessed translation unit
+// Lines[i] maps the columns of the (i+1)th line of Text to the original sources
+type Source struct {
+	Text  string
+	Lines [][]Segment
+}
+
+// Segment is the text of a preprocessed line from Column, which comes from Pos
+// the columns of a segment advance with the original columns unless it is a macro expansion,
+// which is Fixed at the macro name
+type Segment struct {
+	Column int
+	Pos    scanner.Position
+	Fixed  bool
+}
+
+// Macro is `#define Name Body` or `#define Name(Parameters) Body` if IsFunction
+type Macro struct {
+	Name       string
+	Parameters []string
+	IsFunction bool
+	Body       string
+}
+
+// condition is the state of an #if group
+type condition struct {
+	pos     scanner.Position
+	active  bool
+	taken   bool
+	hasElse bool
+}
+
+const maxIncludeDepth = 200
+
+type Preprocessor struct {
+	IncludePaths []string
+
+	macros map[string]*Macro
+	depth  int
+	text   []string
+	lines  [][]Segment
+}
+
+// Preprocess expands the directives and macros of src read from filename
+func Preprocess(filename string, src string, includePaths []string) (*Source, error) {
+	p := &Preprocessor{IncludePaths: includePaths, macros: map[string]*Macro{}}
+
+	err := p.processFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Source{Text: strings.Join(p.text, "\n"), Lines: p.lines}, nil
+}
+
+func (p *Preprocessor) processFile(filename string, src string) error {
+	physicalLines := strings.Split(src, "\n")
+	conditions := []*condition{}
+	inComment := false
+
+	i := 0
+
+	// readLine returns the next line without comments, joining lines ending with a backslash
+	// and the original positions of its bytes
+	readLine := func() (string, []scanner.Position) {
+		var line string
+		var origins []scanner.Position
+
+		for {
+			physicalLine := strings.TrimSuffix(physicalLines[i], "\r")
+			for column := 1; column <= len(physicalLine); column++ {
+				origins = append(origins, scanner.Position{Filename: filename, Line: i + 1, Column: column})
+			}
+
+			line += physicalLine
+			i++
+
+			if !strings.HasSuffix(line, "\\") || i >= len(physicalLines) {
+				break
+			}
+
+			line = line[:len(line)-1]
+			origins = origins[:len(origins)-1]
+		}
+
+		line, indexes := stripComments(line, &inComment)
+
+		stripped := make([]scanner.Position, len(indexes))
+		for j, index := range indexes {
+			stripped[j] = origins[index]
+		}
+
+		return line, stripped
+	}
+
+	for i < len(physicalLines) {
+		pos := scanner.Position{Filename: filename, Line: i + 1, Column: 1}
+		line, origins := readLine()
+		active := len(conditions) == 0 || conditions[len(conditions)-1].active
+
+		directive := strings.TrimSpace(line)
+		if !strings.HasPrefix(directive, "#") {
+			if !active {
+				continue
+			}
+
+			expanded, positions, err := p.expandLine(line, origins)
+
+			// the arguments of a macro call continue to the following lines,
+			// which are joined to this line and keep their own positions
+			for isUnterminatedArguments(err) && i < len(physicalLines) {
+				end := scanner.Position{Filename: filename, Line: i, Column: len(physicalLines[i-1]) + 1}
+				next, nextOrigins := readLine()
+
+				line += " " + next
+				origins = append(append(origins, end), nextOrigins...)
+				expanded, positions, err = p.expandLine(line, origins)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			p.text = append(p.text, expanded)
+			p.lines = append(p.lines, segmentsOf(positions, pos))
+			continue
+		}
+
+		directive = strings.TrimSpace(directive[1:])
+		name := identifierPrefix(directive)
+		argument := strings.TrimSpace(directive[len(name):])
+
+		var top *condition
+		if len(conditions) > 0 {
+			top = conditions[len(conditions)-1]
+		}
+
+		switch name {
+		case "if", "ifdef", "ifndef":
+			if !active {
+				conditions = append(conditions, &condition{pos: pos, taken: true})
+				continue
+			}
+
+			value, err := p.evaluateCondition(name, argument, pos)
+			if err != nil {
+				return err
+			}
+
+			conditions = append(conditions, &condition{pos: pos, active: value, taken: value})
+
+		case "elif":
+			if top == nil {
+				return SemanticError{Pos: pos, Err: errors.New("#elif without #if")}
+			}
+
+			if top.hasElse {
+				return SemanticError{Pos: pos, Err: errors.New("#elif after #else")}
+			}
+
+			if top.taken {
+				top.active = false
+				continue
+			}
+
+			value, err := p.evaluateCondition("if", argument, pos)
+			if err != nil {
+				return err
+			}
+
+			top.active, top.taken = value, value
+
+		case "else":
+			if top == nil {
+				return SemanticError{Pos: pos, Err: errors.New("#else without #if")}
+			}
+
+			if top.hasElse {
+				return SemanticError{Pos: pos, Err: errors.New("#else after #else")}
+			}
+
+			top.active, top.taken, top.hasElse = !top.taken, true, true
+
+		case "endif":
+			if top == nil {
+				return SemanticError{Pos: pos, Err: errors.New("#endif without #if")}
+			}
+
+			conditions = conditions[:len(conditions)-1]
+
+		default:
+			if !active {
+				continue
+			}
+
+			err := p.processDirective(name, argument, pos)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if inComment {
+		return SemanticError{
+			Pos: scanner.Position{Filename: filename, Line: len(physicalLines), Column: 1},
+			Err: errors.New("unterminated comment"),
+		}
+	}
+
+	if len(conditions) > 0 {
+		return SemanticError{Pos: conditions[len(conditions)-1].pos, Err: errors.New("unterminated #if")}
+	}
+
+	return nil
+}
+
+func (p *Preprocessor) processDirective(name string, argument string, pos scanner.Position) error {
+	switch name {
+	case "":
+		// null directive
+		return nil
+
+	case "define":
+		return p.define(argument, pos)
+
+	case "undef":
+		if !isIdentifier(argument) {
+			return SemanticError{Pos: pos, Err: errors.New("macro names must be identifiers")}
+		}
+
+		delete(p.macros, argument)
+		return nil
+
+	case "include":
+		return p.include(argument, pos)
+
+	case "error":
+		return SemanticError{Pos: pos, Err: fmt.Errorf("#error %s", argument)}
+	}
+
+	return SemanticError{Pos: pos, Err: fmt.Errorf("invalid preprocessing directive #%s", name)}
+}
+
+func (p *Preprocessor) define(argument string, pos scanner.Position) error {
+	name := identifierPrefix(argument)
+	if name == "" {
+		return SemanticError{Pos: pos, Err: errors.New("macro names must be identifiers")}
+	}
+
+	if name == "defined" || name == "__LINE__" || name == "__FILE__" {
+		return SemanticError{Pos: pos, Err: fmt.Errorf("cannot define `%s`", name)}
+	}
+
+	macro := &Macro{Name: name}
+	rest := argument[len(name):]
+
+	// a function-like macro has `(` right after its name
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return SemanticError{Pos: pos, Err: fmt.Errorf("missing `)` in parameters of macro `%s`", name)}
+		}
+
+		macro.IsFunction = true
+		if parameters := strings.TrimSpace(rest[1:end]); parameters != "" {
+			for _, parameter := range strings.Split(parameters, ",") {
+				parameter = strings.TrimSpace(parameter)
+				if !isIdentifier(parameter) {
+					return SemanticError{Pos: pos, Err: fmt.Errorf("invalid parameter `%s` of macro `%s`", parameter, name)}
+				}
+
+				macro.Parameters = append(macro.Parameters, parameter)
+			}
+		}
+
+		rest = rest[end+1:]
+	}
+
+	macro.Body = strings.TrimSpace(rest)
+
+	if defined, ok := p.macros[name]; ok && !isSameMacro(defined, macro) {
+		return SemanticError{Pos: pos, Err: fmt.Errorf("macro `%s` redefined", name)}
+	}
+
+	p.macros[name] = macro
+	return nil
+}
+
+func isSameMacro(a *Macro, b *Macro) bool {
+	return a.IsFunction == b.IsFunction &&
+		strings.Join(a.Parameters, ",") == strings.Join(b.Parameters, ",") &&
+		strings.Join(strings.Fields(a.Body), " ") == strings.Join(strings.Fields(b.Body), " ")
+}
+
+// include processes a file found in the directory of the current file or IncludePaths
+// `<file>` is searched only in IncludePaths
+func (p *Preprocessor) include(argument string, pos scanner.Position) error {
+	if len(argument) < 2 {
+		return SemanticError{Pos: pos, Err: errors.New("#include expects \"file\" or <file>")}
+	}
+
+	name := argument[1 : len(argument)-1]
+	directories := p.IncludePaths
+
+	switch {
+	case argument[0] == '"' && argument[len(argument)-1] == '"':
+		directories = append([]string{filepath.Dir(pos.Filename)}, directories...)
+
+	case argument[0] == '<' && argument[len(argument)-1] == '>':
+
+	default:
+		return SemanticError{Pos: pos, Err: errors.New("#include expects \"file\" or <file>")}
+	}
+
+	if p.depth >= maxIncludeDepth {
+		return SemanticError{Pos: pos, Err: errors.New("#include nested too deeply")}
+	}
+
+	for _, directory := range directories {
+		filename := filepath.Join(directory, name)
+		if filepath.IsAbs(name) {
+			filename = name
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return SemanticError{Pos: pos, Err: err}
+		}
+
+		p.depth++
+		err = p.processFile(filename, string(data))
+		p.depth--
+
+		return err
+	}
+
+	return SemanticError{Pos: pos, Err: fmt.Errorf("cannot find include file `%s`", name)}
+}
+
+// expandLine expands the macros in a line whose bytes come from origins
+// it returns the expanded line and the original positions of its bytes,
+// which are at the macro name for the expansion of a macro
+func (p *Preprocessor) expandLine(line string, origins []scanner.Position) (string, []scanner.Position, error) {
+	var positions []scanner.Position
+	copied := 0
+
+	expanded, err := replaceIdentifiers(line, func(name string, end int) (string, int, error) {
+		start := end - len(name)
+		replacement, next, err := p.expandName(line, name, end, origins[start], map[string]bool{})
+		if err != nil {
+			return "", 0, err
+		}
+
+		positions = append(positions, origins[copied:start]...)
+		if replacement == name {
+			positions = append(positions, origins[start:end]...)
+		} else {
+			for range replacement {
+				positions = append(positions, origins[start])
+			}
+		}
+
+		copied = next
+		return replacement, next, nil
+	})
+
+	return expanded, append(positions, origins[copied:]...), err
+}
+
+// segmentsOf joins the positions of the bytes in a line into segments
+// pos is the position of an empty line
+func segmentsOf(positions []scanner.Position, pos scanner.Position) []Segment {
+	var segments []Segment
+
+	for i, origin := range positions {
+		if len(segments) > 0 {
+			last := &segments[len(segments)-1]
+			advanced := last.Pos
+			advanced.Column += i + 1 - last.Column
+
+			if !last.Fixed && origin == advanced {
+				continue
+			}
+
+			if origin == last.Pos && (last.Fixed || i+1-last.Column == 1) {
+				last.Fixed = true
+				continue
+			}
+		}
+
+		segments = append(segments, Segment{Column: i + 1, Pos: origin})
+	}
+
+	if len(segments) == 0 {
+		segments = append(segments, Segment{Column: 1, Pos: pos})
+	}
+
+	return segments
+}
+
+// expand replaces macros in text except those in disabled, which are being expanded
+func (p *Preprocessor) expand(text string, pos scanner.Position, disabled map[string]bool) (string, error) {
+	return replaceIdentifiers(text, func(name string, end int) (string, int, error) {
+		return p.expandName(text, name, end, pos, disabled)
+	})
+}
+
+// expandName returns the expansion of the identifier name which ends at text[end]
+// and the end of the replaced text, which includes the arguments of a function-like macro
+func (p *Preprocessor) expandName(text string, name string, end int, pos scanner.Position, disabled map[string]bool) (string, int, error) {
+	switch name {
+	case "__LINE__":
+		return strconv.Itoa(pos.Line), end, nil
+
+	case "__FILE__":
+		return strconv.Quote(pos.Filename), end, nil
+	}
+
+	macro, ok := p.macros[name]
+	if !ok || disabled[name] {
+		return name, end, nil
+	}
+
+	body := macro.Body
+
+	if macro.IsFunction {
+		open := end
+		for open < len(text) && (text[open] == ' ' || text[open] == '\t') {
+			open++
+		}
+
+		// a function-like macro name without arguments is not expanded
+		if open == len(text) || text[open] != '(' {
+			return name, end, nil
+		}
+
+		arguments, close, err := splitArguments(text, open)
+		if err != nil {
+			return "", 0, SemanticError{Pos: pos, Err: fmt.Errorf("%w invoking macro `%s`", err, name)}
+		}
+
+		if len(macro.Parameters) == 0 && len(arguments) == 1 && arguments[0] == "" {
+			arguments = nil
+		}
+
+		if len(arguments) != len(macro.Parameters) {
+			return "", 0, SemanticError{
+				Pos: pos,
+				Err: fmt.Errorf("macro `%s` expects %d arguments, got %d", name, len(macro.Parameters), len(arguments)),
+			}
+		}
+
+		for i, argument := range arguments {
+			arguments[i], err = p.expand(argument, pos, disabled)
+			if err != nil {
+				return "", 0, err
+			}
+		}
+
+		body, _ = replaceIdentifiers(body, func(parameter string, parameterEnd int) (string, int, error) {
+			for i, name := range macro.Parameters {
+				if name == parameter {
+					return arguments[i], parameterEnd, nil
+				}
+			}
+
+			return parameter, parameterEnd, nil
+		})
+
+		end = close
+	}
+
+	inner := map[string]bool{name: true}
+	for name := range disabled {
+		inner[name] = true
+	}
+
+	expanded, err := p.expand(body, pos, inner)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// spaces keep the expansion from being joined with the tokens around it
+	return " " + expanded + " ", end, nil
+}
+
+// evaluateCondition evaluates the argument of #if, #ifdef or #ifndef
+func (p *Preprocessor) evaluateCondition(directive string, argument string, pos scanner.Position) (bool, error) {
+	if directive == "ifdef" || directive == "ifndef" {
+		if !isIdentifier(argument) {
+			return false, SemanticError{Pos: pos, Err: errors.New("macro names must be identifiers")}
+		}
+
+		return p.isDefined(argument) == (directive == "ifdef"), nil
+	}
+
+	argument = definedPattern.ReplaceAllStringFunc(argument, func(s string) string {
+		name := definedPattern.FindStringSubmatch(s)
+		if p.isDefined(name[1] + name[2]) {
+			return "1"
+		}
+
+		return "0"
+	})
+
+	argument, err := p.expand(argument, pos, map[string]bool{})
+	if err != nil {
+		return false, err
+	}
+
+	// identifiers which are not macros are 0
+	argument, err = replaceIdentifiers(argument, func(name string, end int) (string, int, error) {
+		if name == "defined" {
+			return "", 0, SemanticError{Pos: pos, Err: errors.New("`defined` without a macro name")}
+		}
+
+		return "0", end, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if strings.TrimSpace(argument) == "" {
+		return false, SemanticError{Pos: pos, Err: fmt.Errorf("#%s with no expression", directive)}
+	}
+
+	value, err := evaluateConditionExpression(argument)
+	if err != nil {
+		return false, SemanticError{Pos: pos, Err: fmt.Errorf("%v in #%s", err, directive)}
+	}
+
+	return value != 0, nil
+}
+
+var definedPattern = regexp.MustCompile(`\bdefined\s*(?:\(\s*([A-Za-z_]\w*)\s*\)|([A-Za-z_]\w*))`)
+
+func (p *Preprocessor) isDefined(name string) bool {
+	_, ok := p.macros[name]
+
+	return ok || name == "__LINE__" || name == "__FILE__"
+}
+
+// evaluateConditionExpression evaluates an integer constant expression of #if
+func evaluateConditionExpression(expression string) (int, error) {
+	l := new(Lexer)
+	l.Init(expression)
+
+	tokens := []string{}
+	var sym yySymType
+	for l.Lex(&sym) != -1 {
+		tokens = append(tokens, sym.token.lit)
+	}
+
+	e := &conditionEvaluator{tokens: tokens}
+	value, err := e.conditional()
+	if err != nil {
+		return 0, err
+	}
+
+	if e.index < len(e.tokens) {
+		return 0, fmt.Errorf("unexpected `%s`", e.tokens[e.index])
+	}
+
+	return value, nil
+}
+
+type conditionEvaluator struct {
+	tokens []string
+	index  int
+}
+
+var conditionPrecedences = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, ">": 7, "<=": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+func (e *conditionEvaluator) peek() string {
+	if e.index < len(e.tokens) {
+		return e.tokens[e.index]
+	}
+
+	return ""
+}
+
+func (e *conditionEvaluator) next() string {
+	token := e.peek()
+	e.index++
+
+	return token
+}
+
+func (e *conditionEvaluator) conditional() (int, error) {
+	value, err := e.binary(1)
+	if err != nil || e.peek() != "?" {
+		return value, err
+	}
+
+	e.next()
+	trueValue, err := e.conditional()
+	if err != nil {
+		return 0, err
+	}
+
+	if token := e.next(); token != ":" {
+		return 0, errors.New("expected `:`")
+	}
+
+	falseValue, err := e.conditional()
+	if err != nil {
+		return 0, err
+	}
+
+	if value != 0 {
+		return trueValue, nil
+	}
+
+	return falseValue, nil
+}
+
+func (e *conditionEvaluator) binary(precedence int) (int, error) {
+	left, err := e.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		operator := e.peek()
+		operatorPrecedence := conditionPrecedences[operator]
+		if operatorPrecedence < precedence {
+			return left, nil
+		}
+
+		e.next()
+		right, err := e.binary(operatorPrecedence + 1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch operator {
+		case "&&":
+			left = boolToInt(left != 0 && right != 0)
+
+		case "||":
+			left = boolToInt(left != 0 || right != 0)
+
+		default:
+			ok, value := calculate(operator, left, right)
+			if !ok {
+				return 0, errors.New("division by zero")
+			}
+
+			left = value
+		}
+	}
+}
+
+func (e *conditionEvaluator) unary() (int, error) {
+	token := e.next()
+
+	switch token {
+	case "(":
+		value, err := e.conditional()
+		if err != nil {
+			return 0, err
+		}
+
+		if e.next() != ")" {
+			return 0, errors.New("missing `)`")
+		}
+
+		return value, nil
+
+	case "-", "+", "!", "~":
+		value, err := e.unary()
+		if err != nil {
+			return 0, err
+		}
+
+		switch token {
+		case "-":
+			_, value = calculate("-", 0, value)
+		case "!":
+			_, value = calculate("!", value, 0)
+		case "~":
+			_, value = calculate("^", value, -1)
+		}
+
+		return value, nil
+
+	case "":
+		return 0, errors.New("unexpected end of expression")
+	}
+
+	if strings.HasPrefix(token, "'") {
+		return parseCharacter(token)
+	}
+
+	if '0' <= token[0] && token[0] <= '9' {
+		value, err := parseInteger(token)
+		return wrap(value), err
+	}
+
+	return 0, fmt.Errorf("unexpected `%s`", token)
+}
+
+// replaceIdentifiers calls replace for each identifier in text outside of literals
+// replace returns the replacement and the end of the replaced text
+func replaceIdentifiers(text string, replace func(name string, end int) (string, int, error)) (string, error) {
+	var result strings.Builder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == '"' || c == '\'':
+			end := literalEnd(text, i)
+			result.WriteString(text[i:end])
+			i = end
+
+		case isIdentifierStart(c):
+			end := i
+			for end < len(text) && isIdentifierPart(text[end]) {
+				end++
+			}
+
+			replacement, next, err := replace(text[i:end], end)
+			if err != nil {
+				return "", err
+			}
+
+			result.WriteString(replacement)
+			i = next
+
+		case '0' <= c && c <= '9':
+			// a number like 0x1f is not an identifier
+			end := i
+			for end < len(text) && (isIdentifierPart(text[end]) || text[end] == '.') {
+				end++
+			}
+
+			result.WriteString(text[i:end])
+			i = end
+
+		default:
+			result.WriteByte(c)
+			i++
+		}
+	}
+
+	return result.String(), nil
+}
+
+// splitArguments splits the arguments of a macro call starting with `(` at text[open]
+// it returns the arguments and the end of the call
+func splitArguments(text string, open int) ([]string, int, error) {
+	arguments := []string{}
+	depth := 0
+	start := open + 1
+
+	for i := open + 1; i < len(text); {
+		switch text[i] {
+		case '"', '\'':
+			i = literalEnd(text, i)
+			continue
+
+		case '(':
+			depth++
+
+		case ')':
+			if depth == 0 {
+				arguments = append(arguments, strings.TrimSpace(text[start:i]))
+				return arguments, i + 1, nil
+			}
+
+			depth--
+
+		case ',':
+			if depth == 0 {
+				arguments = append(arguments, strings.TrimSpace(text[start:i]))
+				start = i + 1
+			}
+		}
+
+		i++
+	}
+
+	return nil, 0, errUnterminatedArguments
+}
+
+var errUnterminatedArguments = errors.New("unterminated argument list")
+
+func isUnterminatedArguments(err error) bool {
+	e, ok := err.(SemanticError)
+	return ok && errors.Is(e.Err, errUnterminatedArguments)
+}
+
+// stripComments replaces comments in line with a space
+// inComment is whether the line starts in a block comment, and is updated for the next line
+// it also returns the indexes in line of the bytes of the result
+func stripComments(line string, inComment *bool) (string, []int) {
+	var result strings.Builder
+	var indexes []int
+
+	for i := 0; i < len(line); {
+		if *inComment {
+			end := strings.Index(line[i:], "*/")
+			if end < 0 {
+				break
+			}
+
+			*inComment = false
+			result.WriteByte(' ')
+			indexes = append(indexes, i+end+1)
+			i += end + 2
+			continue
+		}
+
+		switch {
+		case line[i] == '"' || line[i] == '\'':
+			end := literalEnd(line, i)
+			result.WriteString(line[i:end])
+			for j := i; j < end; j++ {
+				indexes = append(indexes, j)
+			}
+			i = end
+
+		case strings.HasPrefix(line[i:], "//"):
+			return result.String(), indexes
+
+		case strings.HasPrefix(line[i:], "/*"):
+			*inComment = true
+			i += 2
+
+		default:
+			result.WriteByte(line[i])
+			indexes = append(indexes, i)
+			i++
+		}
+	}
+
+	return result.String(), indexes
+}
+
+// literalEnd returns the end of the string or char literal starting at text[start]
+func literalEnd(text string, start int) int {
+	quote := text[start]
+
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+
+		case quote:
+			return i + 1
+		}
+	}
+
+	return len(text)
+}
+
+func identifierPrefix(s string) string {
+	end := 0
+	for end < len(s) && (isIdentifierPart(s[end]) && (end > 0 || isIdentifierStart(s[end]))) {
+		end++
+	}
+
+	return s[:end]
+}
+
+func isIdentifier(s string) bool {
+	return s != "" && identifierPrefix(s) == s
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || '0' <= c && c <= '9'
+}
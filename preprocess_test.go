@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreprocess(t *testing.T) {
+	cases := []struct {
+		Source string
+		Text   string
+	}{
+		{"#define N 10\nint a[N];", "int a[ 10 ];"},
+		{"#define ADD(a, b) ((a) + (b))\nADD(1, ADD(x, 2))", " ((1) + ( ((x) + (2)) )) "},
+		{"#define F(x) x\nint F;", "int F;"},
+		{"#define A B\n#define B A\nA", "  A  "},
+		{"#define N 1\n\"N\" 'N' N", "\"N\" 'N'  1 "},
+		{"#define N 1\n#undef N\nN", "N"},
+		{"#define SUM(a, b) \\\n  a + b\nSUM(1, 2)", " 1 + 2 "},
+		{"#define MAX(a, b) ((a) > (b) ? (a) : (b))\nMAX(1, // one\n  2) + 3", " ((1) > (2) ? (1) : (2))  + 3"},
+		{"a /* b\nc */ d // e", "a   d"},
+		{"#if 1 + 2 * 3 == 7 && !0\nyes\n#else\nno\n#endif", "yes"},
+		{"#define N 2\n#if N > 3\none\n#elif N > 1\ntwo\n#elif N > 0\nthree\n#endif", "two"},
+		{"#ifdef N\nyes\n#endif\n#ifndef N\nno\n#endif", "no"},
+		{"#if defined(N) || defined M\nyes\n#else\nno\n#endif", "no"},
+		{"#if 0\n#if 1\nyes\n#else\nno\n#endif\n#endif", ""},
+		{"#if UNDEFINED == 0 && (0x10 >> 4) == 1 ? 1 : 0\nyes\n#endif", "yes"},
+		{"\n__LINE__ __FILE__", "2 \"file.sc\""},
+	}
+
+	for _, c := range cases {
+		source, err := Preprocess("file.sc", c.Source, nil)
+		if err != nil {
+			t.Errorf("%q: %v", c.Source, err)
+			continue
+		}
+
+		text := strings.TrimSpace(strings.Replace(source.Text, "\n", "", -1))
+		if text != strings.TrimSpace(c.Text) {
+			t.Errorf("%q: expect %q, got %q", c.Source, c.Text, source.Text)
+		}
+	}
+
+	// a macro call over lines is placed at its first line, and the rest keeps its position
+	source, err := Preprocess("file.sc", "#define F(a, b) a\nF(x,\n  y) w\nz", nil)
+	if err != nil || len(source.Lines) != 2 {
+		t.Fatalf("expect 2 lines, got %+v (%v)", source, err)
+	}
+
+	first, next := source.Lines[0], source.Lines[1]
+	last := first[len(first)-1]
+	if !(first[0].Pos.Line == 2 && last.Pos.Line == 3 && last.Pos.Column == 5 && next[0].Pos.Line == 4) {
+		t.Errorf("expect lines 2, 3:5 and 4, got %+v", source.Lines)
+	}
+}
+
+func TestPreprocessPosition(t *testing.T) {
+	src := `#define F(a, b) a + b
+#define VERYLONGMACRONAME 1
+int x;
+int main() {
+  x = F(1,
+    2); x = y;
+  x = VERYLONGMACRONAME + z; /* comment */ x = w;
+}
+`
+	_, errs := CompileFile("file.sc", src, nil, true)
+
+	expected := []string{"file.sc:6:14", "file.sc:7:28", "file.sc:7:49"}
+	if len(errs) != len(expected) {
+		t.Fatalf("expect %d errors, got %v", len(expected), errs)
+	}
+
+	for i, err := range errs {
+		e, ok := err.(SemanticError)
+		if !ok || formatPosition(e.Pos) != expected[i] {
+			t.Errorf("expect error at %v, got %v", expected[i], err)
+		}
+	}
+}
+
+func TestPreprocessInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "small-c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"main.sc":         "#include \"local.h\"\n#include <lib.h>\n\nint main() {\n  return 1 +;\n}\n",
+		"local.h":         "#define ONE 1\n",
+		"include/lib.h":   "#ifndef LIB_H\n#define LIB_H\n#include <lib.h>\nint lib();\n#endif\n",
+		"include/error.h": "#error broken",
+	}
+
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(filename), 0777)
+		ioutil.WriteFile(filename, []byte(content), 0666)
+	}
+
+	filename := filepath.Join(dir, "main.sc")
+	includePaths := []string{filepath.Join(dir, "include")}
+
+	source, err := Preprocess(filename, files["main.sc"], includePaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(source.Text, "int lib();") {
+		t.Errorf("expect lib.h to be included, got %q", source.Text)
+	}
+
+	_, err = ParseSource(source)
+	expected := filename + ":5:14: "
+	if !(err != nil && strings.HasPrefix(err.Error(), expected)) {
+		t.Errorf("expect error at %v, got %v", expected, err)
+	}
+
+	_, errs := CompileFile(filename, "#include <error.h>", includePaths, true)
+	if !(len(errs) == 1 && strings.Contains(errs[0].Error(), "broken")) {
+		t.Errorf("expect #error, got %v", errs)
+	}
+
+	_, err = Preprocess(filename, "#include \"local.h\"", nil)
+	if err != nil {
+		t.Errorf("expect local.h to be found next to main.sc, got %v", err)
+	}
+
+	_, err = Preprocess(filename, "#include <local.h>", nil)
+	if err == nil {
+		t.Error("expect <local.h> not to be found, got nil")
+	}
+}
+
+func TestPreprocessError(t *testing.T) {
+	sources := []string{
+		"#if 1\nint a;",
+		"#endif",
+		"#else",
+		"#if 1\n#else\n#elif 1\n#endif",
+		"#if 1 +\n#endif",
+		"#if 1 / 0\n#endif",
+		"#if\n#endif",
+		"#ifdef 1\n#endif",
+		"#define F(a) a\nF(1, 2)",
+		"#define F(a) a\nF(1",
+		"#define F(1) 1",
+		"#define N 1\n#define N 2",
+		"#define defined 1",
+		"#include",
+		"#include \"no_such_file.h\"",
+		"#pragma once",
+		"/* unterminated",
+	}
+
+	for _, src := range sources {
+		_, err := Preprocess("", src, nil)
+		if err == nil {
+			t.Errorf("expect error for %q, got nil", src)
+			continue
+		}
+
+		if _, ok := err.(SemanticError); !ok {
+			t.Errorf("expect error with a position for %q, got %v", src, err)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 void main() {
   int **a[2];
+  int *p;
+  p = a;
 }
\ No newline at end of file
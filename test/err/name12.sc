@@ -0,0 +1,4 @@
+int main() {
+  x = 0;
+  return 0;
+}
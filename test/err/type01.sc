@@ -1,3 +1,3 @@
-void *f(int *a) {
-  return a;
+int f(void *a) {
+  return *a;
 }
@@ -1 +1,5 @@
 void *a[8];
+
+void f() {
+  a[0] = a[1] + 1;
+}
@@ -1,4 +1,5 @@
 void f() {
   int *a;
-  &a;
+  int **b;
+  a = &b;
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -13,23 +14,35 @@ type SymbolType interface {
 }
 
 type BasicType struct {
-	Name string
+	Name  string
+	Alias string
+	Const bool
 }
 
 func (t BasicType) ByteSize() int {
-	if t.Name == "int" {
+	switch t.Name {
+	case "int", "unsigned int":
 		return 4
+	case "char":
+		return 1
 	}
 
 	return 0
 }
 
 func (t BasicType) String() string {
+	if t.Const {
+		return "const " + t.Name
+	}
+
 	return t.Name
 }
 
+// PointerType is `const` if the pointer itself is, `const int*` is a pointer to `const int`
 type PointerType struct {
 	Value SymbolType
+	Alias string
+	Const bool
 }
 
 func (t PointerType) ByteSize() int {
@@ -37,12 +50,22 @@ func (t PointerType) ByteSize() int {
 }
 
 func (t PointerType) String() string {
-	return t.Value.String() + "*"
+	qualifier := ""
+	if t.Const {
+		qualifier = " const"
+	}
+
+	if _, isFunction := t.Value.(FunctionType); isFunction {
+		return "(" + t.Value.String() + ")*" + qualifier
+	}
+
+	return t.Value.String() + "*" + qualifier
 }
 
 type ArrayType struct {
 	Value SymbolType
 	Size  int
+	Alias string
 }
 
 func (t ArrayType) ByteSize() int {
@@ -50,7 +73,181 @@ func (t ArrayType) ByteSize() int {
 }
 
 func (t ArrayType) String() string {
-	return fmt.Sprintf("%s[%d]", t.Value.String(), t.Size)
+	// int m[3][4] is an array of 3 int[4]
+	base, sizes := t.Value, fmt.Sprintf("[%d]", t.Size)
+	for {
+		inner, isArray := base.(ArrayType)
+		if !isArray {
+			break
+		}
+
+		base, sizes = inner.Value, sizes+fmt.Sprintf("[%d]", inner.Size)
+	}
+
+	return base.String() + sizes
+}
+
+// arrayOf returns the array of symbolType with sizes from outermost to innermost
+func arrayOf(symbolType SymbolType, sizes []int) SymbolType {
+	for i := len(sizes) - 1; i >= 0; i-- {
+		symbolType = ArrayType{Value: symbolType, Size: sizes[i]}
+	}
+
+	return symbolType
+}
+
+// scalarCount returns the number of scalars in symbolType
+func scalarCount(symbolType SymbolType) int {
+	if arrayType, isArray := symbolType.(ArrayType); isArray {
+		return arrayType.Size * scalarCount(arrayType.Value)
+	}
+
+	return 1
+}
+
+// scalarType returns the element type of the innermost array
+func scalarType(symbolType SymbolType) SymbolType {
+	for {
+		arrayType, isArray := symbolType.(ArrayType)
+		if !isArray {
+			return symbolType
+		}
+
+		symbolType = arrayType.Value
+	}
+}
+
+type StructField struct {
+	Name   string
+	Type   SymbolType
+	Offset int
+}
+
+// StructType is referred by pointer so that a struct can have pointers to itself
+// `const struct s` is another StructType which shares the fields of `struct s`
+type StructType struct {
+	Name   string
+	Fields []*StructField
+	Const  bool
+
+	qualified   *StructType
+	unqualified *StructType
+	complete    bool
+}
+
+// IsComplete reports whether the members of t are declared
+// `struct s` before `struct s { ... }` is incomplete, and only pointers can refer to it
+func (t *StructType) IsComplete() bool {
+	return t.base().complete
+}
+
+// isIncomplete reports whether symbolType is an incomplete struct or an array of them
+func isIncomplete(symbolType SymbolType) bool {
+	switch t := symbolType.(type) {
+	case ArrayType:
+		return isIncomplete(t.Value)
+
+	case *StructType:
+		return !t.IsComplete()
+	}
+
+	return false
+}
+
+// constOf returns the const qualified type of t, which is the same for each struct
+func (t *StructType) constOf() *StructType {
+	if t.Const {
+		return t
+	}
+
+	if t.qualified == nil {
+		t.qualified = &StructType{Name: t.Name, Fields: t.Fields, Const: true, unqualified: t}
+	}
+
+	return t.qualified
+}
+
+// base returns t without the const qualifier
+func (t *StructType) base() *StructType {
+	if t.unqualified != nil {
+		return t.unqualified
+	}
+
+	return t
+}
+
+func (t *StructType) ByteSize() int {
+	size := 0
+	for _, field := range t.Fields {
+		size = field.Offset + field.Type.ByteSize()
+	}
+
+	return align(size, alignment(t))
+}
+
+func (t *StructType) String() string {
+	if t.Const {
+		return "const struct " + t.Name
+	}
+
+	return "struct " + t.Name
+}
+
+// AddField appends a field placed at the next aligned offset
+func (t *StructType) AddField(name string, fieldType SymbolType) {
+	offset := 0
+	if len(t.Fields) > 0 {
+		last := t.Fields[len(t.Fields)-1]
+		offset = last.Offset + last.Type.ByteSize()
+	}
+
+	t.Fields = append(t.Fields, &StructField{
+		Name:   name,
+		Type:   fieldType,
+		Offset: align(offset, alignment(fieldType)),
+	})
+
+	if t.qualified != nil {
+		t.qualified.Fields = t.Fields
+	}
+}
+
+func (t *StructType) Field(name string) *StructField {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// alignment returns the byte boundary which values of symbolType are placed on
+func alignment(symbolType SymbolType) int {
+	switch t := symbolType.(type) {
+	case ArrayType:
+		return alignment(t.Value)
+
+	case *StructType:
+		max := 1
+		for _, field := range t.Fields {
+			if a := alignment(field.Type); a > max {
+				max = a
+			}
+		}
+
+		return max
+	}
+
+	if symbolType.ByteSize() == 0 {
+		return 1
+	}
+
+	return symbolType.ByteSize()
+}
+
+func align(size int, boundary int) int {
+	return (size + boundary - 1) / boundary * boundary
 }
 
 type FunctionType struct {
@@ -75,6 +272,14 @@ func Int() SymbolType {
 	return BasicType{Name: "int"}
 }
 
+func Unsigned() SymbolType {
+	return BasicType{Name: "unsigned int"}
+}
+
+func Char() SymbolType {
+	return BasicType{Name: "char"}
+}
+
 func Void() SymbolType {
 	return BasicType{Name: "void"}
 }
@@ -83,6 +288,275 @@ func Pointer(symbolType SymbolType) SymbolType {
 	return PointerType{Value: symbolType}
 }
 
+// withAlias returns symbolType named by a typedef, structs keep their own name
+func withAlias(symbolType SymbolType, alias string) SymbolType {
+	switch t := symbolType.(type) {
+	case BasicType:
+		t.Alias = alias
+		return t
+
+	case PointerType:
+		t.Alias = alias
+		return t
+
+	case ArrayType:
+		t.Alias = alias
+		return t
+	}
+
+	return symbolType
+}
+
+func aliasOf(symbolType SymbolType) string {
+	switch t := symbolType.(type) {
+	case BasicType:
+		return t.Alias
+
+	case PointerType:
+		return t.Alias
+
+	case ArrayType:
+		return t.Alias
+	}
+
+	return ""
+}
+
+// typeString formats symbolType for diagnostics like `size_t (aka int)`
+func typeString(symbolType SymbolType) string {
+	if alias := aliasOf(symbolType); alias != "" {
+		return fmt.Sprintf("%v (aka %v)", alias, symbolType)
+	}
+
+	return symbolType.String()
+}
+
+// constOf returns symbolType qualified by const
+// a const array is an array of const elements
+func constOf(symbolType SymbolType) (SymbolType, error) {
+	switch t := symbolType.(type) {
+	case BasicType:
+		t.Const = true
+		return t, nil
+
+	case PointerType:
+		t.Const = true
+		return t, nil
+
+	case ArrayType:
+		value, err := constOf(t.Value)
+		t.Value = value
+		return t, err
+
+	case *StructType:
+		return t.constOf(), nil
+	}
+
+	return symbolType, fmt.Errorf("`const %v` is not supported", symbolType)
+}
+
+// isConst reports whether a value of symbolType cannot be modified
+func isConst(symbolType SymbolType) bool {
+	switch t := symbolType.(type) {
+	case BasicType:
+		return t.Const
+
+	case PointerType:
+		return t.Const
+
+	case ArrayType:
+		return isConst(t.Value)
+
+	case *StructType:
+		return t.Const
+	}
+
+	return false
+}
+
+// isInteger returns true for int, unsigned int and char, which are converted to each other implicitly
+func isInteger(symbolType SymbolType) bool {
+	t, ok := symbolType.(BasicType)
+	return ok && (t.Name == "int" || t.Name == "unsigned int" || t.Name == "char")
+}
+
+func isUnsigned(symbolType SymbolType) bool {
+	t, ok := symbolType.(BasicType)
+	return ok && t.Name == "unsigned int"
+}
+
+// arithmeticType returns the type which integer operands are converted to by the usual arithmetic conversions
+// char is promoted to int, and int is converted to unsigned int if the other operand is unsigned
+func arithmeticType(left SymbolType, right SymbolType) SymbolType {
+	if isUnsigned(left) || isUnsigned(right) {
+		return Unsigned()
+	}
+
+	return Int()
+}
+
+// operatorOf returns the operator of e for the IR
+// the operators which depend on signedness get the suffix `u` for unsigned operands, like `/u`
+func operatorOf(e *BinaryExpression) string {
+	leftType, _ := typeOfExpression(e.Left)
+	rightType, _ := typeOfExpression(e.Right)
+	if !isInteger(leftType) || !isInteger(rightType) {
+		return e.Operator
+	}
+
+	switch e.Operator {
+	case "/", "%", "<", ">", "<=", ">=":
+		if isUnsigned(arithmeticType(leftType, rightType)) {
+			return e.Operator + "u"
+		}
+
+	case ">>":
+		// the result of a shift has the type of the left operand
+		if isUnsigned(leftType) {
+			return e.Operator + "u"
+		}
+	}
+
+	return e.Operator
+}
+
+// isCompatible checks that a value of type `from` can be stored to `to`
+// void* is converted to and from any other object pointer implicitly
+func isCompatible(to SymbolType, from SymbolType) bool {
+	// `int*` from `const int*` would allow writes through it
+	if toPointer, ok := to.(PointerType); ok {
+		if fromPointer, ok := from.(PointerType); ok && isConst(fromPointer.Value) && !isConst(toPointer.Value) {
+			return false
+		}
+	}
+
+	isObjectPointer := func(t SymbolType) bool {
+		return isPointer(t) && !isFunctionPointer(t)
+	}
+
+	if isObjectPointer(to) && isObjectPointer(from) && (isVoidPointer(to) || isVoidPointer(from)) {
+		return true
+	}
+
+	return isSameType(to, from) || (isInteger(to) && isInteger(from))
+}
+
+// isCompatibleValue is isCompatible which also accepts the null pointer constant `0` for pointers
+func isCompatibleValue(to SymbolType, value Expression, from SymbolType) bool {
+	return isCompatible(to, from) || (isPointer(to) && isNullPointerConstant(value))
+}
+
+// isSameType compares two types structurally
+func isSameType(a SymbolType, b SymbolType) bool {
+	switch a := a.(type) {
+	case BasicType:
+		b, ok := b.(BasicType)
+		return ok && a.Name == b.Name
+
+	case PointerType:
+		b, ok := b.(PointerType)
+		return ok && isSameType(a.Value, b.Value)
+
+	case ArrayType:
+		b, ok := b.(ArrayType)
+		return ok && a.Size == b.Size && isSameType(a.Value, b.Value)
+
+	case *StructType:
+		// each struct declaration has its own type
+		b, ok := b.(*StructType)
+		return ok && a.base() == b.base()
+
+	case FunctionType:
+		b, ok := b.(FunctionType)
+		if !ok || len(a.Args) != len(b.Args) || !isSameType(a.Return, b.Return) {
+			return false
+		}
+
+		for i := range a.Args {
+			if !isSameType(a.Args[i], b.Args[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func isPointer(symbolType SymbolType) bool {
+	_, ok := symbolType.(PointerType)
+	return ok
+}
+
+// typeOfSizeof returns the type whose size `sizeof` gives
+// arrays do not decay to pointers here, so sizeof a of int a[10] is 40
+func typeOfSizeof(e *SizeofExpression) (SymbolType, error) {
+	t := e.Type
+
+	switch value := e.Value.(type) {
+	case nil:
+
+	case *IdentifierExpression:
+		t = value.Symbol.Type
+
+	case *MemberExpression:
+		field, err := findField(value)
+		if err != nil {
+			return nil, err
+		}
+
+		t = field.Type
+
+	default:
+		valueType, err := typeOfExpression(value)
+		if err != nil {
+			return nil, err
+		}
+
+		t = valueType
+		if isArrayDereference(value) {
+			// sizeof m[0] of int m[3][4] is the size of the row
+			rowType, _ := typeOfExpression(value.(*UnaryExpression).Value)
+			t = rowType.(PointerType).Value
+		}
+	}
+
+	if t.ByteSize() == 0 {
+		return nil, SemanticError{
+			Pos: e.Pos(),
+			Err: fmt.Errorf("type error: sizeof of `%v` which has no size", typeString(t)),
+		}
+	}
+
+	return t, nil
+}
+
+// isCastable checks that `(to) value` is valid for a value of type `from`
+// integers and pointers are converted to each other, and anything can be discarded by `(void)`
+func isCastable(to SymbolType, from SymbolType) bool {
+	if isSameType(to, Void()) {
+		return true
+	}
+
+	isScalar := func(t SymbolType) bool {
+		return isInteger(t) || isPointer(t)
+	}
+
+	return isScalar(to) && isScalar(from)
+}
+
+func isVoidPointer(symbolType SymbolType) bool {
+	t, ok := symbolType.(PointerType)
+	return ok && isSameType(t.Value, Void())
+}
+
+// isNullPointerConstant reports whether expression is the literal `0`
+func isNullPointerConstant(expression Expression) bool {
+	number, ok := expression.(*NumberExpression)
+	return ok && number.Value == "0"
+}
+
 // CheckType checks that ast is well-typed
 // statements must be analyzed (should have symbol information)
 func CheckType(statements []Statement) error {
@@ -106,12 +580,22 @@ func CheckTypeOfStatement(statement Statement) error {
 		for _, declarator := range s.Declarators {
 			identifier := findIdentifierExpression(declarator.Identifier)
 			t := identifier.Symbol.Type
-			isVoid := strings.Contains(t.String(), "void")
 
-			if isVoid && t.String() != "void" {
+			// void a[3]; has no size, but void *a[3]; is fine
+			if _, isArray := t.(ArrayType); isArray && isSameType(scalarType(t), Void()) {
 				return SemanticError{
 					Pos: s.Pos(),
-					Err: fmt.Errorf("type error: void pointer is not allowed"),
+					Err: fmt.Errorf("type error: array `%v` of `void` is not allowed", identifier.Name),
+				}
+			}
+
+			for _, value := range initializerValues(declarator) {
+				if value == nil {
+					continue
+				}
+
+				if err := checkTypeOfInitializer(t, value); err != nil {
+					return err
 				}
 			}
 		}
@@ -122,17 +606,33 @@ func CheckTypeOfStatement(statement Statement) error {
 		identifier := findIdentifierExpression(s.Identifier)
 		funcType, _ := identifier.Symbol.Type.(FunctionType)
 		for _, argType := range funcType.Args {
-			isVoid := strings.Contains(argType.String(), "void")
-			if isVoid {
+			if isSameType(argType, Void()) {
+				return SemanticError{
+					Pos: s.Pos(),
+					Err: errors.New("type error: parameter cannot have type `void`"),
+				}
+			}
+
+			if _, isStruct := argType.(*StructType); isStruct {
 				return SemanticError{
 					Pos: s.Pos(),
-					Err: fmt.Errorf("type error: void pointer is not allowed"),
+					Err: fmt.Errorf("type error: struct parameter `%v` is not supported, pass a pointer", argType),
 				}
 			}
 		}
 
+		if _, isStruct := funcType.Return.(*StructType); isStruct {
+			return SemanticError{
+				Pos: s.Pos(),
+				Err: fmt.Errorf("type error: struct return value `%v` is not supported, return a pointer", funcType.Return),
+			}
+		}
+
 		return CheckTypeOfStatement(s.Statement)
 
+	case *StructDeclaration, *EnumDeclaration, *TypedefDeclaration:
+		return nil
+
 	case *ExpressionStatement:
 		if s.Value == nil {
 			return nil
@@ -142,6 +642,11 @@ func CheckTypeOfStatement(statement Statement) error {
 		return err
 
 	case *CompoundStatement:
+		err := CheckType(s.Declarations)
+		if err != nil {
+			return err
+		}
+
 		return CheckType(s.Statements)
 
 	case *IfStatement:
@@ -160,6 +665,35 @@ func CheckTypeOfStatement(statement Statement) error {
 
 		return CheckType(s.Statements())
 
+	case *DoWhileStatement:
+		err := CheckType(s.Statements())
+		if err != nil {
+			return err
+		}
+
+		return checkTypeOfCondition(s.Condition)
+
+	case *SwitchStatement:
+		t, err := typeOfExpression(s.Value)
+		if err != nil {
+			return err
+		}
+
+		if !isInteger(t) {
+			return SemanticError{
+				Pos: s.Value.Pos(),
+				Err: fmt.Errorf("type error: switch value must be int, not `%v`", typeString(t)),
+			}
+		}
+
+		return CheckTypeOfStatement(s.Statement)
+
+	case *CaseStatement:
+		return CheckTypeOfStatement(s.Statement)
+
+	case *BreakStatement, *ContinueStatement:
+		return nil
+
 	case *ReturnStatement:
 		var valueType SymbolType
 
@@ -175,10 +709,10 @@ func CheckTypeOfStatement(statement Statement) error {
 		}
 
 		functionType := s.FunctionSymbol.Type.(FunctionType)
-		if valueType.String() != functionType.Return.String() {
+		if !isCompatibleValue(functionType.Return, s.Value, valueType) {
 			return SemanticError{
 				Pos: s.Pos(),
-				Err: fmt.Errorf("type error: must return %v, not %v", functionType.Return, valueType),
+				Err: fmt.Errorf("type error: must return %v, not %v", typeString(functionType.Return), typeString(valueType)),
 			}
 		}
 
@@ -194,13 +728,24 @@ func CheckTypeOfStatement(statement Statement) error {
 func typeOfExpression(expression Expression) (SymbolType, error) {
 	switch e := expression.(type) {
 	case *NumberExpression:
+		if e.IsUnsigned {
+			return Unsigned(), nil
+		}
+
 		return BasicType{Name: "int"}, nil
 
+	case *StringExpression:
+		return Pointer(Char()), nil
+
 	case *IdentifierExpression:
 		switch t := e.Symbol.Type.(type) {
 		case ArrayType:
 			return Pointer(t.Value), nil
 
+		case FunctionType:
+			// a function name is the pointer to the function
+			return Pointer(t), nil
+
 		default:
 			return e.Symbol.Type, nil
 
@@ -230,13 +775,73 @@ func typeOfExpression(expression Expression) (SymbolType, error) {
 
 		switch e.Operator {
 		case "&":
-			if valueType.String() == "int" {
-				return Pointer(valueType), nil
+			// &a of int a[10] is int (*)[10] although a itself decays to int*
+			// &f of a function f is the same as f
+			if identifier, isIdentifier := e.Value.(*IdentifierExpression); isIdentifier {
+				if identifier.Symbol.IsConstant() {
+					return nil, SemanticError{
+						Pos: e.Pos(),
+						Err: fmt.Errorf("type error: cannot take the address of enumerator `%v`", identifier.Name),
+					}
+				}
+
+				switch t := identifier.Symbol.Type.(type) {
+				case ArrayType, FunctionType:
+					return Pointer(t), nil
+				}
+			}
+
+			// &*p is p, which keeps the row type of &m[i] of int m[3][4]
+			if value, isUnary := e.Value.(*UnaryExpression); isUnary && value.Operator == "*" {
+				return typeOfExpression(value.Value)
+			}
+
+			switch valueType.(type) {
+			case BasicType, PointerType, *StructType:
+				if valueType.String() != "void" {
+					return Pointer(valueType), nil
+				}
+			}
+
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: cannot take the address of `%v`", typeString(valueType)),
+			}
+
+		case "~", "!":
+			if isInteger(valueType) && e.Operator == "~" {
+				return arithmeticType(valueType, Int()), nil
+			}
+
+			if isInteger(valueType) {
+				return Int(), nil
+			}
+
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: %v%v", e.Operator, typeString(valueType)),
 			}
 
 		case "*":
 			switch t := valueType.(type) {
 			case PointerType:
+				if isVoidPointer(t) {
+					return nil, SemanticError{
+						Pos: e.Pos(),
+						Err: errors.New("type error: cannot dereference `void*`, assign it to an object pointer first"),
+					}
+				}
+
+				// *p of int (*p)[4] is int[4] which decays to int*
+				if arrayType, isArray := t.Value.(ArrayType); isArray {
+					return Pointer(arrayType.Value), nil
+				}
+
+				// *f of a function pointer is the function which decays to f again
+				if isFunctionPointer(t) {
+					return t, nil
+				}
+
 				return t.Value, nil
 
 			default:
@@ -250,6 +855,101 @@ func typeOfExpression(expression Expression) (SymbolType, error) {
 	case *BinaryExpression:
 		return typeOfBinaryExpression(e)
 
+	case *ConditionalExpression:
+		if err := checkTypeOfCondition(e.Condition); err != nil {
+			return nil, err
+		}
+
+		trueType, err := typeOfExpression(e.TrueValue)
+		if err != nil {
+			return nil, err
+		}
+
+		falseType, err := typeOfExpression(e.FalseValue)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, isStruct := trueType.(*StructType); isStruct {
+			return nil, SemanticError{
+				Pos: e.TrueValue.Pos(),
+				Err: fmt.Errorf("type error: `?:` is not supported for struct values: %v", trueType),
+			}
+		}
+
+		// p ? q : 0 has the type of q
+		if isPointer(trueType) && isNullPointerConstant(e.FalseValue) {
+			return trueType, nil
+		}
+
+		if isPointer(falseType) && isNullPointerConstant(e.TrueValue) {
+			return falseType, nil
+		}
+
+		// p ? v : q of void *v is void*
+		if isPointer(trueType) && isPointer(falseType) {
+			if isVoidPointer(trueType) {
+				return trueType, nil
+			}
+
+			if isVoidPointer(falseType) {
+				return falseType, nil
+			}
+		}
+
+		if !isSameType(trueType, falseType) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: both arms of `?:` must have the same type: %v and %v", typeString(trueType), typeString(falseType)),
+			}
+		}
+
+		return trueType, nil
+
+	case *SizeofExpression:
+		if _, err := typeOfSizeof(e); err != nil {
+			return nil, err
+		}
+
+		return Int(), nil
+
+	case *CastExpression:
+		valueType, err := typeOfExpression(e.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isCastable(e.Type, valueType) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: cannot cast `%v` to `%v`", typeString(valueType), typeString(e.Type)),
+			}
+		}
+
+		return e.Type, nil
+
+	case *PostfixExpression:
+		// a++  is typed as  a += 1
+		return typeOfBinaryExpression(&BinaryExpression{
+			Left:     e.Value,
+			Operator: e.Operator[:1] + "=",
+			Right:    &NumberExpression{Value: "1"},
+		})
+
+	case *MemberExpression:
+		field, err := findField(e)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := field.Type.(type) {
+		case ArrayType:
+			return Pointer(t.Value), nil
+
+		default:
+			return field.Type, nil
+		}
+
 	case *FunctionCallExpression:
 		var args []Expression
 		switch arg := e.Argument.(type) {
@@ -261,13 +961,30 @@ func typeOfExpression(expression Expression) (SymbolType, error) {
 			}
 		}
 
-		identifier := findIdentifierExpression(e.Identifier)
-		funcType := identifier.Symbol.Type.(FunctionType)
+		calleeType, err := typeOfExpression(e.Identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		// f(a) and (*f)(a) both call through a pointer to function
+		if !isFunctionPointer(calleeType) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: `%v` is not a function", typeString(calleeType)),
+			}
+		}
+
+		funcType := calleeType.(PointerType).Value.(FunctionType)
+
+		name := "function pointer"
+		if identifier, ok := e.Identifier.(*IdentifierExpression); ok {
+			name = identifier.Name
+		}
 
 		if len(args) != len(funcType.Args) {
 			return nil, SemanticError{
 				Pos: e.Pos(),
-				Err: fmt.Errorf("function `%v` must be called with %v arguments, not %v", identifier.Name, len(funcType.Args), len(args)),
+				Err: fmt.Errorf("function `%v` must be called with %v arguments, not %v", name, len(funcType.Args), len(args)),
 			}
 		}
 
@@ -277,10 +994,10 @@ func typeOfExpression(expression Expression) (SymbolType, error) {
 				return nil, err
 			}
 
-			if argType.String() != funcType.Args[i].String() {
+			if !isCompatibleValue(funcType.Args[i], arg, argType) {
 				return nil, SemanticError{
 					Pos: arg.Pos(),
-					Err: fmt.Errorf("type error: argument type mismatch: %v", argType.String()),
+					Err: fmt.Errorf("type error: argument type mismatch: expect %v, not %v", typeString(funcType.Args[i]), typeString(argType)),
 				}
 			}
 		}
@@ -305,56 +1022,265 @@ func typeOfBinaryExpression(e *BinaryExpression) (SymbolType, error) {
 		return nil, rightErr
 	}
 
+	_, leftIsStruct := leftType.(*StructType)
+	_, rightIsStruct := rightType.(*StructType)
+	if leftIsStruct || rightIsStruct {
+		return nil, SemanticError{
+			Pos: e.Pos(),
+			Err: fmt.Errorf("type error: `%v` is not supported for struct values: %v %v %v", e.Operator, leftType, e.Operator, rightType),
+		}
+	}
+
 	if e.IsArithmetic() {
-		if leftType.String() == "int" && rightType.String() == "int" {
-			return BasicType{Name: "int"}, nil
+		if isVoidPointer(leftType) || isVoidPointer(rightType) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: arithmetic on `void*` is not allowed: %v %v %v", typeString(leftType), e.Operator, typeString(rightType)),
+			}
+		}
+
+		if isFunctionPointer(leftType) || isFunctionPointer(rightType) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: fmt.Errorf("type error: arithmetic on function pointer is not allowed: %v %v %v", typeString(leftType), e.Operator, typeString(rightType)),
+			}
+		}
+
+		if isInteger(leftType) && isInteger(rightType) {
+			return arithmeticType(leftType, rightType), nil
 		}
 
 		switch e.Operator {
 		case "+":
-			// int* + int, int + int* -> int*
-			if (leftType.String() == "int*" && rightType.String() == "int") || (leftType.String() == "int" && rightType.String() == "int*") {
-				return Pointer(Int()), nil
+			// T* + int, int + T* -> T*
+			if isPointer(leftType) && isInteger(rightType) {
+				return leftType, nil
 			}
 
-			// int** + int, int + int** -> int**
-			if (leftType.String() == "int**" && rightType.String() == "int") || (leftType.String() == "int" && rightType.String() == "int**") {
-				return Pointer(Pointer(Int())), nil
+			if isInteger(leftType) && isPointer(rightType) {
+				return rightType, nil
 			}
 
 		case "-":
-			if leftType.String() == "int*" && rightType.String() == "int" {
-				return Pointer(Int()), nil
+			// T* - int -> T*
+			if isPointer(leftType) && isInteger(rightType) {
+				return leftType, nil
 			}
 
-			if leftType.String() == "int**" && rightType.String() == "int" {
-				return Pointer(Pointer(Int())), nil
+			// T* - T* -> int, the number of elements between them
+			if isPointer(leftType) && isSameType(leftType, rightType) {
+				return Int(), nil
 			}
 		}
 	}
 
+	if e.IsBitwise() {
+		if isInteger(leftType) && isInteger(rightType) {
+			if e.Operator == "<<" || e.Operator == ">>" {
+				return arithmeticType(leftType, Int()), nil
+			}
+
+			return arithmeticType(leftType, rightType), nil
+		}
+	}
+
 	if e.IsAssignment() {
-		if leftType.String() == rightType.String() {
+		valueType := rightType
+		if e.IsCompoundAssignment() {
+			// a += b  is typed as  a = a + b
+			arithmeticType, err := typeOfBinaryExpression(arithmeticOf(e))
+			if err != nil {
+				return nil, err
+			}
+
+			valueType = arithmeticType
+		}
+
+		if member, isMember := e.Left.(*MemberExpression); isMember {
+			field, _ := findField(member)
+			if _, isArray := field.Type.(ArrayType); isArray {
+				return nil, SemanticError{
+					Pos: e.Pos(),
+					Err: fmt.Errorf("type error: array member `%v` is not assignable", member.Member),
+				}
+			}
+		}
+
+		if isArrayDereference(e.Left) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: errors.New("type error: array is not assignable"),
+			}
+		}
+
+		if isFunctionDereference(e.Left) {
+			return nil, SemanticError{
+				Pos: e.Pos(),
+				Err: errors.New("type error: function is not assignable"),
+			}
+		}
+
+		if isCompatibleValue(leftType, e.Right, valueType) {
 			return leftType, nil
 		}
 	}
 
 	if e.IsLogical() {
-		if leftType.String() == "int" && rightType.String() == "int" {
+		if isInteger(leftType) && isInteger(rightType) {
 			return Int(), nil
 		}
 	}
 
 	if e.IsEqual() {
-		if leftType.String() == rightType.String() {
+		if isCompatible(leftType, rightType) {
+			return Int(), nil
+		}
+
+		// p == 0, 0 != p
+		isEquality := e.Operator == "==" || e.Operator == "!="
+		if isEquality && (isCompatibleValue(leftType, e.Right, rightType) || isCompatibleValue(rightType, e.Left, leftType)) {
 			return Int(), nil
 		}
 	}
 
 	return nil, SemanticError{
 		Pos: e.Pos(),
-		Err: fmt.Errorf("type error: %v %v %v", leftType.String(), e.Operator, rightType.String()),
+		Err: fmt.Errorf("type error: %v %v %v", typeString(leftType), e.Operator, typeString(rightType)),
+	}
+}
+
+// initializerValues returns the initial values of the scalars of declarator in memory order
+func initializerValues(declarator *Declarator) []Expression {
+	if declarator.Initializer == nil {
+		return nil
+	}
+
+	identifier := findIdentifierExpression(declarator.Identifier)
+	values, _ := flattenInitializer(identifier.Name, identifier.Symbol.Type, declarator.Initializer)
+
+	return values
+}
+
+func checkTypeOfInitializer(t SymbolType, value Expression) error {
+	t = scalarType(t)
+
+	if _, isStruct := t.(*StructType); isStruct {
+		return SemanticError{
+			Pos: value.Pos(),
+			Err: fmt.Errorf("type error: initializer of `%v` is not supported", typeString(t)),
+		}
+	}
+
+	valueType, err := typeOfExpression(value)
+	if err != nil {
+		return err
+	}
+
+	if !isCompatibleValue(t, value, valueType) {
+		return SemanticError{
+			Pos: value.Pos(),
+			Err: fmt.Errorf("type error: `%v` cannot be initialized with `%v`", typeString(t), typeString(valueType)),
+		}
+	}
+
+	return nil
+}
+
+// arithmeticOf returns `a op b` of the compound assignment `a op= b`
+func arithmeticOf(e *BinaryExpression) *BinaryExpression {
+	return &BinaryExpression{
+		Left:     e.Left,
+		Operator: strings.TrimSuffix(e.Operator, "="),
+		Right:    e.Right,
+	}
+}
+
+func isArrayPointer(symbolType SymbolType) bool {
+	if t, isPointer := symbolType.(PointerType); isPointer {
+		_, isArray := t.Value.(ArrayType)
+		return isArray
+	}
+
+	return false
+}
+
+// isArrayDereference reports whether expression is `*p` which refers to an array like m[i] of int m[3][4]
+func isArrayDereference(expression Expression) bool {
+	e, isUnary := expression.(*UnaryExpression)
+	if !isUnary || e.Operator != "*" {
+		return false
+	}
+
+	valueType, err := typeOfExpression(e.Value)
+	return err == nil && isArrayPointer(valueType)
+}
+
+func isFunctionPointer(symbolType SymbolType) bool {
+	if t, isPointer := symbolType.(PointerType); isPointer {
+		_, isFunction := t.Value.(FunctionType)
+		return isFunction
+	}
+
+	return false
+}
+
+// isFunctionDereference reports whether expression is `*f` of a function pointer f, which is f itself
+func isFunctionDereference(expression Expression) bool {
+	e, isUnary := expression.(*UnaryExpression)
+	if !isUnary || e.Operator != "*" {
+		return false
+	}
+
+	valueType, err := typeOfExpression(e.Value)
+	return err == nil && isFunctionPointer(valueType)
+}
+
+func isStructPointer(symbolType SymbolType) bool {
+	if t, isPointer := symbolType.(PointerType); isPointer {
+		_, isStruct := t.Value.(*StructType)
+		return isStruct
+	}
+
+	return false
+}
+
+// findField returns the field which member expression refers to
+func findField(e *MemberExpression) (*StructField, error) {
+	targetType, err := typeOfExpression(e.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	structType, ok := targetType.(*StructType)
+	if !ok {
+		return nil, SemanticError{
+			Pos: e.Pos(),
+			Err: fmt.Errorf("type error: member `%v` of non-struct type `%v`", e.Member, typeString(targetType)),
+		}
+	}
+
+	if !structType.IsComplete() {
+		return nil, SemanticError{
+			Pos: e.Pos(),
+			Err: fmt.Errorf("type error: member `%v` of incomplete type `%v`", e.Member, structType),
+		}
 	}
+
+	field := structType.Field(e.Member)
+	if field == nil {
+		return nil, SemanticError{
+			Pos: e.Pos(),
+			Err: fmt.Errorf("type error: `%v` has no member `%v`", structType, e.Member),
+		}
+	}
+
+	// the members of a const struct are read-only
+	if structType.Const {
+		fieldType, _ := constOf(field.Type)
+		return &StructField{Name: field.Name, Type: fieldType, Offset: field.Offset}, nil
+	}
+
+	return field, nil
 }
 
 func checkTypeOfCondition(condition Expression) error {
@@ -367,10 +1293,10 @@ func checkTypeOfCondition(condition Expression) error {
 		return err
 	}
 
-	if t.String() != "int" {
+	if !isInteger(t) {
 		return SemanticError{
 			Pos: condition.Pos(),
-			Err: fmt.Errorf("type error: condition must be int, not `%v`", t),
+			Err: fmt.Errorf("type error: condition must be int, not `%v`", typeString(t)),
 		}
 	}
 
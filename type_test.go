@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -183,6 +184,40 @@ func TestCheckTypeOfWhileStatement(t *testing.T) {
 	}
 }
 
+func TestCheckTypeOfDoWhileStatement(t *testing.T) {
+	{
+		statements := ast(`
+      int main() {
+        int i;
+        do {
+          i = i - 1;
+        } while (i > 0);
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	{
+		statements := ast(`
+      int main() {
+        int *i;
+        do {
+          break;
+        } while (i);
+      }
+    `)
+
+		err := CheckType(statements)
+		if err == nil {
+			t.Error("expect type error in condition, got nil")
+		}
+	}
+}
+
 func TestCheckTypeOfFunctionCallExpression(t *testing.T) {
 	{
 		statements := ast(`
@@ -314,7 +349,7 @@ func TestCheckVoidType(t *testing.T) {
 
 	{
 		statements := ast(`
-      void *a;
+      void a[3];
       int main() {
         ;
       }
@@ -349,4 +384,665 @@ func TestTypeSize(t *testing.T) {
 	if arrayType.ByteSize() != expected {
 		t.Errorf("expect size of array[4] == %v, got %v", expected, arrayType.ByteSize())
 	}
+
+	if Char().ByteSize() != 1 {
+		t.Errorf("expect size of char == 1, got %v", Char().ByteSize())
+	}
+
+	charArrayType := ArrayType{Value: Char(), Size: 6}
+	if charArrayType.ByteSize() != 6 {
+		t.Errorf("expect size of char[6] == 6, got %v", charArrayType.ByteSize())
+	}
+
+	structType := &StructType{Name: "s"}
+	structType.AddField("c", Char())
+	structType.AddField("i", Int())
+	structType.AddField("d", ArrayType{Value: Char(), Size: 3})
+	if structType.Field("i").Offset != 4 || structType.Field("d").Offset != 8 {
+		t.Errorf("expect int member to be aligned, got %v", structType.Fields)
+	}
+
+	if structType.ByteSize() != 12 {
+		t.Errorf("expect size of struct s == 12, got %v", structType.ByteSize())
+	}
+}
+
+func TestTypeOfStringExpression(t *testing.T) {
+	symbolType, err := typeOfExpression(&StringExpression{Value: "hello"})
+	if err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+
+	if symbolType.String() != "char*" {
+		t.Errorf("expect char* type, got %v", symbolType)
+	}
+}
+
+func TestCheckTypeOfChar(t *testing.T) {
+	{
+		statements := ast(`
+      char f(char c) {
+        return c + 1;
+      }
+
+      int main() {
+        char c, s[4], *p;
+        int i;
+
+        c = 'a';
+        i = c;
+        c = i;
+        p = s + 1;
+        *p = f(i);
+        if (c) {
+          return *(p - 1);
+        }
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	{
+		statements := ast(`
+      int main() {
+        char *p;
+        int *q;
+        q = p;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err == nil {
+			t.Error("expect char* to int* assignment error, but nil")
+		}
+	}
+}
+
+func TestCheckTypeOfStruct(t *testing.T) {
+	{
+		statements := ast(`
+      struct node {
+        int value;
+        char name[4];
+        struct node *next;
+      };
+
+      int main() {
+        struct node n, *p;
+        char *name;
+
+        p = &n;
+        (*p).next = p;
+        (*(*p).next).value = 1;
+        name = n.name;
+        return n.value + *(n.name + 1);
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"struct s { int x; }; int main() { struct s a; a.y = 1; }",
+		"struct s { int x; }; int main() { int a; a.x = 1; }",
+		"struct s { int x; }; int main() { struct s a, b; a = b; }",
+		"struct s { int x; }; int main() { struct s a; return a + 1; }",
+		"struct s { int a[2]; }; int main() { struct s a; a.a = 0; }",
+		"struct s { int x; }; int f(struct s a) { return 0; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfBitwise(t *testing.T) {
+	{
+		statements := ast(`
+      int main() {
+        int a;
+        char c;
+        a = (a % 3) & (c | 1) ^ ~a << 2 >> c;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"int main() { int *p; return p % 2; }",
+		"int main() { int *p; return p & 1; }",
+		"int main() { int *p; return 1 << p; }",
+		"int main() { int *p; return ~p; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfLogicalNot(t *testing.T) {
+	err := CheckType(ast(`
+      int main() {
+        char c;
+        return !c + !!1;
+      }
+    `))
+
+	if err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+
+	err = CheckType(ast(`
+      int main() {
+        int *p;
+        return !p;
+      }
+    `))
+
+	if err == nil {
+		t.Error("expect type error for `!p`, but nil")
+	}
+}
+
+func TestCheckTypeOfCompoundAssignment(t *testing.T) {
+	{
+		statements := ast(`
+      int main() {
+        int a, *p;
+        char c;
+        p += 1;
+        p -= a;
+        c *= 2;
+        a <<= c;
+        return *p++ + c--;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"int main() { int *p; p *= 2; }",
+		"int main() { int a, *p; a += p; }",
+		"int main() { int *p, *q; p += q; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfConditional(t *testing.T) {
+	{
+		statements := ast(`
+      int main() {
+        int a, *p, *q;
+        p = a ? p : q;
+        return a > 0 ? a : 0 - a;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"int main() { int a, *p; return a ? a : p; }",
+		"int main() { int a; char *s; s = a ? s : 1; }",
+		"int main() { int *p; return p ? 1 : 0; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfInitializer(t *testing.T) {
+	{
+		statements := ast(`
+      char *s = "hello";
+      int main() {
+        char c = 'a', text[3] = {c, 'b'};
+        int *p = &c, n = c;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err == nil {
+			t.Error("expect char* to int* initializer error, but nil")
+		}
+	}
+
+	sources := []string{
+		"int *p = 1;",
+		"int main() { int a, *p = a; }",
+		"int main() { int *p, a[2] = {1, p}; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfMultiDimensionalArray(t *testing.T) {
+	matrix := arrayOf(Int(), []int{3, 4})
+	if matrix.String() != "int[3][4]" || matrix.ByteSize() != 48 {
+		t.Errorf("expect int[3][4] of 48 bytes, got %v of %v bytes", matrix, matrix.ByteSize())
+	}
+
+	{
+		statements := ast(`
+      int f(int m[][4]) {
+        int *row;
+        row = *(m + 1);
+        return *(*(m + 2) + 3) + *row;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"int main() { int m[3][4]; *(m + 1) = 0; }",
+		"int main() { int m[3][4], *p; p = m; }",
+		"int f(int m[][4]) { int n[2][5]; return f(n); }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfPointer(t *testing.T) {
+	{
+		statements := ast(`
+      struct point { int x; };
+
+      int main() {
+        int a[10], *p, **pp, ***ppp, n;
+        char *s, *t;
+        struct point points[2], *q;
+
+        ppp = &pp;
+        ppp = ppp + 1 - 1;
+        pp = &p;
+        p = &*(a + 3);
+        n = p - a;
+        n = t - s;
+        n = (q + 1) - points;
+        n = p < a + 10 && s >= t;
+        p = 0;
+        n = p == 0 || 0 != pp;
+        return ***ppp;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"int main() { int *p; char *s; return p - s; }",
+		"int main() { int *p, *q; p = p + q; }",
+		"int main() { int *p; return p * 2; }",
+		"int main() { int *p; char *s; return p < s; }",
+		"int main() { int *p; return p < 0; }",
+		"int main() { int *p; p = 1; }",
+		"int main() { int **pp, *p; pp = &pp; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfVoidPointer(t *testing.T) {
+	{
+		statements := ast(`
+      void *copy(void *dst, void *src, int n) {
+        char *d, *s;
+        d = dst;
+        s = src;
+        while (n--) *d++ = *s++;
+        return dst;
+      }
+
+      int main() {
+        int a[4], b[4], *p, n;
+        void *v;
+        v = a;
+        p = copy(b, v, 16);
+        p = n ? v : p;
+        return v == p || v != 0;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	cases := []struct {
+		Source  string
+		Message string
+	}{
+		{"int main() { void *v; return *v; }", "cannot dereference `void*`"},
+		{"int main() { void *v; v = v + 1; }", "arithmetic on `void*`"},
+		{"int main() { void *v; v++; }", "arithmetic on `void*`"},
+		{"int main() { void *v; int n; n = v; }", "int = void*"},
+		{"int main() { void **v; int **p; p = v; }", "int** = void**"},
+	}
+
+	for _, c := range cases {
+		err := CheckType(ast(c.Source))
+		if err == nil || !strings.Contains(err.Error(), c.Message) {
+			t.Errorf("expect `%v` for `%v`, got %v", c.Message, c.Source, err)
+		}
+	}
+}
+
+func TestCheckTypeOfCast(t *testing.T) {
+	{
+		statements := ast(`
+      struct node { int value; };
+
+      int main() {
+        int a, *p;
+        char c;
+        struct node *n;
+        void *v;
+
+        c = (char) a;
+        a = (int) p + (int) c;
+        p = (int*) a;
+        n = (struct node*) p;
+        v = (void*) (char**) v;
+        (void) a;
+        return (int) n;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	sources := []string{
+		"struct s { int a; }; int main() { struct s v; return (int) v; }",
+		"struct s { int a; }; int main() { int a; (struct s) a; }",
+		"int main() { int a; return (void) a; }",
+		"int main() { int *p; char c; c = (char*) p; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfSizeof(t *testing.T) {
+	statements, _ := Parse(`
+    struct pair { char c; int v; };
+
+    int main() {
+      int a[10], m[3][4], *p;
+      struct pair s, *ps;
+      char c;
+      a[0] = sizeof(int) + sizeof(char*) + sizeof a + sizeof m[1] + sizeof(struct pair) + sizeof s.c + sizeof *ps + sizeof c;
+    }
+  `)
+
+	for i, statement := range statements {
+		statements[i] = Walk(statement)
+	}
+
+	if errs := Analyze(statements, &Env{}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if err := CheckType(statements); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+
+	body := statements[1].(*FunctionDefinition).Statement.(*CompoundStatement)
+	value := body.Statements[0].(*ExpressionStatement).Value.(*BinaryExpression).Right
+	isConstant, size := evaluateConstant(value)
+	if !isConstant || size != 4+4+40+16+8+1+8+1 {
+		t.Errorf("expect 82, got %v", size)
+	}
+
+	sources := []string{
+		"int main() { return sizeof(void); }",
+		"void f() {} int main() { return sizeof f(); }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfFunctionPointer(t *testing.T) {
+	{
+		statements := ast(`
+      struct op { int (*apply)(int, int); };
+
+      int add(int a, int b) { return a + b; }
+      int apply(int (*f)(int, int), int a, int b) { return f(a, b) + (*f)(a, b); }
+
+      int main() {
+        int (*f)(int, int), (*g)(int a, int b) = &add, (**pf)(int, int);
+        int (*table[2])(int, int);
+        struct op o;
+
+        f = add;
+        pf = &f;
+        *(table + 1) = *pf;
+        o.apply = g;
+        return apply(add, 1, 2) + f(1, 2) + (**pf)(1, 2) + (*(table + 1))(1, 2) + o.apply(1, 2) + (f == g) + (f != 0);
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+
+		f := findIdentifierExpression(statements[1].(*FunctionDefinition).Identifier).Symbol
+		if f.Type.String() != "(int, int) -> int" {
+			t.Errorf("expect (int, int) -> int, got %v", f.Type)
+		}
+	}
+
+	sources := []string{
+		"int main() { int a; a(1); }",
+		"int f(int a) { return a; } int main() { int (*g)(int, int); g = f; }",
+		"int f(int a) { return a; } int main() { int (*g)(int); return g(1, 2); }",
+		"int f(int a) { return a; } int main() { int (*g)(int); return g(f); }",
+		"int f(int a) { return a; } int main() { int (*g)(int); g = f + 1; }",
+		"int f(int a) { return a; } int main() { int (*g)(int); *g = f; }",
+		"int f(int a) { return a; } int main() { void *p; p = f; }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfTypedef(t *testing.T) {
+	{
+		statements := ast(`
+      typedef int size_t, *int_ptr;
+      typedef int (*binary)(int, int);
+      struct point { int x; };
+      typedef struct point point_t;
+
+      int add(int a, int b) { return a + b; }
+
+      int main() {
+        size_t n;
+        int_ptr p;
+        binary f;
+        point_t s;
+        struct point *q;
+
+        p = &n;
+        f = add;
+        q = &s;
+        return *p + f(n, 1) + (*q).x;
+      }
+    `)
+
+		err := CheckType(statements)
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+
+	err := CheckType(ast("typedef int *int_ptr; int main() { int_ptr p; char c; c = p; }"))
+	if err == nil || !strings.Contains(err.Error(), "int_ptr (aka int*)") {
+		t.Errorf("expect error with the alias, got %v", err)
+	}
+}
+
+func TestCheckTypeOfConst(t *testing.T) {
+	statements := ast(`
+    const int limit = 3;
+    int const *p;
+    int * const q = 0;
+    const char * const * r;
+
+    void print_string(const char *s);
+
+    int main() {
+      int x;
+      const int *cp;
+      cp = &x;
+      cp = &limit;
+      x = *cp + limit;
+      print_string("const");
+      return x;
+    }
+  `)
+
+	if err := CheckType(statements); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+
+	expected := []string{"const int", "const int*", "int* const", "const char* const*"}
+	for i, declaration := range statements[:4] {
+		symbol := findIdentifierExpression(declaration.(*Declaration).Declarators[0].Identifier).Symbol
+		if symbol.Type.String() != expected[i] {
+			t.Errorf("expect %v, got %v", expected[i], symbol.Type)
+		}
+	}
+
+	sources := []string{
+		"const int limit = 3; int main() { int *p; p = &limit; }",
+		"void f(char *s); int main() { const char *s; f(s); }",
+		"struct s { int x; }; void f(struct s *p); int main() { const struct s *p; f(p); }",
+	}
+
+	for _, src := range sources {
+		err := CheckType(ast(src))
+		if err == nil {
+			t.Errorf("expect type error for `%v`, but nil", src)
+		}
+	}
+}
+
+func TestCheckTypeOfUnsigned(t *testing.T) {
+	statements, _ := Parse(`
+    unsigned int u;
+    unsigned v;
+    int i;
+    char c;
+
+    int main() {
+      u = i;
+      i = u;
+      return u + i;
+    }
+  `)
+
+	env := &Env{}
+	Analyze(statements, env)
+	if err := CheckType(statements); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+
+	variable := func(name string) Expression {
+		return &IdentifierExpression{Name: name, Symbol: env.Get(name)}
+	}
+
+	cases := []struct {
+		Expression *BinaryExpression
+		Type       string
+		Operator   string
+	}{
+		{&BinaryExpression{Left: variable("u"), Operator: "+", Right: variable("i")}, "unsigned int", "+"},
+		{&BinaryExpression{Left: variable("c"), Operator: "/", Right: variable("i")}, "int", "/"},
+		{&BinaryExpression{Left: variable("i"), Operator: "/", Right: variable("v")}, "unsigned int", "/u"},
+		{&BinaryExpression{Left: variable("i"), Operator: "<=", Right: variable("u")}, "int", "<=u"},
+		{&BinaryExpression{Left: variable("i"), Operator: ">>", Right: variable("u")}, "int", ">>"},
+		{&BinaryExpression{Left: variable("u"), Operator: ">>", Right: variable("i")}, "unsigned int", ">>u"},
+	}
+
+	for _, c := range cases {
+		symbolType, err := typeOfExpression(c.Expression)
+		if err != nil || symbolType.String() != c.Type || operatorOf(c.Expression) != c.Operator {
+			t.Errorf("expect %v to be %v with `%v`, got %v with `%v` (%v)", formatExpression(c.Expression), c.Type, c.Operator, symbolType, operatorOf(c.Expression), err)
+		}
+	}
 }